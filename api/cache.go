@@ -0,0 +1,188 @@
+/*
+On-disk cache for API responses, keyed by request URL. Protects the daily
+request quota by serving repeated requests from disk until they expire.
+*/
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Forever is used as a TTL for responses that never change, e.g. finished fixtures
+const Forever time.Duration = -1
+
+type CacheMode int
+
+const (
+	// Read from and write to the cache
+	CacheDefault CacheMode = iota
+	// Skip reading the cache but store fresh responses
+	CacheRefresh
+	// Never touch the cache
+	CacheOff
+)
+
+type Cache struct {
+	Dir string
+}
+
+type cacheEntry struct {
+	URL       string          `json:"url"`
+	FetchedAt time.Time       `json:"fetched_at"`
+	ExpiresAt time.Time       `json:"expires_at,omitempty"`
+	Body      json.RawMessage `json:"body"`
+}
+
+type CacheStats struct {
+	Entries int
+	Expired int
+	Bytes   int64
+}
+
+// Creates a Cache that stores its entries in dir
+func NewCache(dir string) *Cache {
+	return &Cache{Dir: dir}
+}
+
+// Path of the cache file for a URL
+func (c *Cache) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Reads an entry from disk
+func readEntry(path string) (cacheEntry, error) {
+	var entry cacheEntry
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return entry, err
+	}
+
+	err = json.Unmarshal(data, &entry)
+	return entry, err
+}
+
+// Checks if an entry is past its TTL
+func (e cacheEntry) expired(now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && now.After(e.ExpiresAt)
+}
+
+// Gets the cached body for a URL if it exists and has not expired
+func (c *Cache) Get(url string) ([]byte, bool) {
+	entry, err := readEntry(c.path(url))
+	if err != nil || entry.URL != url || entry.expired(time.Now()) {
+		return nil, false
+	}
+
+	return entry.Body, true
+}
+
+// Stores the body for a URL for the given TTL
+func (c *Cache) Put(url string, body []byte, ttl time.Duration) error {
+	if ttl == 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return fmt.Errorf("Failed to create cache directory: %v", err)
+	}
+
+	now := time.Now()
+	entry := cacheEntry{URL: url, FetchedAt: now, Body: body}
+	if ttl != Forever {
+		entry.ExpiresAt = now.Add(ttl)
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	// Write then rename so a concurrent reader never sees half an entry
+	tmp, err := os.CreateTemp(c.Dir, "entry-*.tmp")
+	if err != nil {
+		return fmt.Errorf("Failed to write cache entry: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("Failed to write cache entry: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("Failed to write cache entry: %v", err)
+	}
+
+	return os.Rename(tmp.Name(), c.path(url))
+}
+
+// Lists the entry files in the cache directory
+func (c *Cache) files() ([]string, error) {
+	dirEntries, err := os.ReadDir(c.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() && strings.HasSuffix(dirEntry.Name(), ".json") {
+			files = append(files, filepath.Join(c.Dir, dirEntry.Name()))
+		}
+	}
+	return files, nil
+}
+
+// Counts the entries in the cache and their size on disk
+func (c *Cache) Stats() (CacheStats, error) {
+	var stats CacheStats
+
+	files, err := c.files()
+	if err != nil {
+		return stats, err
+	}
+
+	now := time.Now()
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		stats.Entries++
+		stats.Bytes += info.Size()
+
+		entry, err := readEntry(file)
+		if err != nil || entry.expired(now) {
+			stats.Expired++
+		}
+	}
+
+	return stats, nil
+}
+
+// Removes every entry from the cache and returns how many were removed
+func (c *Cache) Clear() (int, error) {
+	files, err := c.files()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, file := range files {
+		if err := os.Remove(file); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
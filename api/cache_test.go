@@ -0,0 +1,122 @@
+/*
+Tests that responses are served from the cache according to the cache mode and
+that entries expire after their TTL.
+*/
+package api
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCacheModes(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     CacheMode
+		requests int32
+		entries  int
+	}{
+		{"default", CacheDefault, 1, 1},
+		{"refresh", CacheRefresh, 2, 1},
+		{"off", CacheOff, 2, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var requests int32
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				respondWith(http.StatusOK, `{"errors": [], "response": ["Regular Season - 1"]}`)(w, r)
+			})
+			client.Cache = NewCache(t.TempDir())
+			client.CacheMode = test.mode
+
+			for i := 0; i < 2; i++ {
+				rounds, err := client.Rounds(39, 2024)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(rounds) != 1 || rounds[0] != "Regular Season - 1" {
+					t.Fatalf("got rounds %v", rounds)
+				}
+			}
+
+			if got := atomic.LoadInt32(&requests); got != test.requests {
+				t.Errorf("got %d requests, want %d", got, test.requests)
+			}
+
+			stats, err := client.Cache.Stats()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stats.Entries != test.entries {
+				t.Errorf("got %d cache entries, want %d", stats.Entries, test.entries)
+			}
+		})
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	cache := NewCache(t.TempDir())
+	body := []byte(`{"response": []}`)
+
+	if err := cache.Put("forever", body, Forever); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Get("forever"); !ok {
+		t.Error("entry cached forever wasn't found")
+	}
+
+	if err := cache.Put("uncached", body, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Get("uncached"); ok {
+		t.Error("entry with no TTL was cached")
+	}
+
+	if err := cache.Put("expiring", body, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := cache.Get("expiring"); ok {
+		t.Error("expired entry was served")
+	}
+
+	stats, err := cache.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Entries != 2 || stats.Expired != 1 {
+		t.Errorf("got %d entries with %d expired, want 2 with 1 expired", stats.Entries, stats.Expired)
+	}
+
+	removed, err := cache.Clear()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 2 {
+		t.Errorf("removed %d entries, want 2", removed)
+	}
+}
+
+func TestCacheLiveFixture(t *testing.T) {
+	var requests int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		respondWith(http.StatusOK, `{"errors": [], "response": [{"fixture": {"id": 1, "status": {"short": "2H"}}}]}`)(w, r)
+	})
+	client.Cache = NewCache(t.TempDir())
+
+	// A live fixture is cached for TTLLive, so asking twice straight away
+	// only sends one request
+	for i := 0; i < 2; i++ {
+		if _, err := client.FixtureByID(1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("got %d requests, want 1", got)
+	}
+}
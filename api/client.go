@@ -0,0 +1,387 @@
+/*
+Client for the API-FOOTBALL endpoints used by premcli. Every command goes
+through a Client so requests are built, sent and decoded in one place.
+*/
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultBaseURL   = "https://api-football-v1.p.rapidapi.com/v3"
+	DefaultHost      = "api-football-v1.p.rapidapi.com"
+	DefaultTimeout   = 15 * time.Second
+	DefaultUserAgent = "premcli"
+)
+
+// How long each kind of response is cached for
+const (
+	TTLTeams     = 7 * 24 * time.Hour
+	TTLLeagues   = 24 * time.Hour
+	TTLRounds    = 6 * time.Hour
+	TTLStandings = 3 * time.Hour
+	TTLUpcoming  = time.Hour
+	TTLLive      = 15 * time.Second
+	// Lineups are announced about an hour before kickoff
+	TTLLineupsPending = 10 * time.Minute
+)
+
+// Client talks to API-FOOTBALL through RapidAPI
+type Client struct {
+	BaseURL   string
+	Key       string
+	Host      string
+	Timeout   time.Duration
+	UserAgent string
+	Cache     *Cache
+	CacheMode CacheMode
+	// File the daily quota is saved to after every request, if set
+	QuotaPath string
+
+	httpClient *http.Client
+}
+
+// Creates a Client with the default RapidAPI settings for the given api key
+func NewClient(key string) *Client {
+	return &Client{
+		BaseURL:   DefaultBaseURL,
+		Key:       key,
+		Host:      DefaultHost,
+		Timeout:   DefaultTimeout,
+		UserAgent: DefaultUserAgent,
+	}
+}
+
+// Builds the full URL for an endpoint and its query parameters
+func (c *Client) buildURL(endpoint string, params url.Values) string {
+	u := strings.TrimRight(c.BaseURL, "/") + "/" + strings.TrimLeft(endpoint, "/")
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+	return u
+}
+
+// Sends a GET request to the endpoint and decodes the JSON body into out.
+// Responses are served from the cache when possible and stored for the duration
+// returned by ttl, which is called after out has been decoded.
+func (c *Client) get(endpoint string, params url.Values, out interface{}, ttl func() time.Duration) error {
+	requestURL := c.buildURL(endpoint, params)
+
+	useCache := c.Cache != nil && c.CacheMode != CacheOff
+	if useCache && c.CacheMode != CacheRefresh {
+		if body, ok := c.Cache.Get(requestURL); ok {
+			if err := json.Unmarshal(body, out); err == nil {
+				return nil
+			}
+		}
+	}
+
+	body, err := c.fetch(requestURL)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(body, out)
+	if err != nil {
+		return fmt.Errorf("Error parsing JSON response: %v", err)
+	}
+
+	if useCache {
+		// A failed write only costs a request next time
+		c.Cache.Put(requestURL, body, ttl())
+	}
+
+	return nil
+}
+
+// Performs the HTTP request and returns the body of a successful response
+func (c *Client) fetch(requestURL string) ([]byte, error) {
+	httpClient := c.httpClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: c.Timeout}
+	}
+
+	req, err := http.NewRequest("GET", requestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating request: %v", err)
+	}
+
+	req.Header.Add("X-RapidAPI-Key", c.Key)
+	req.Header.Add("X-RapidAPI-Host", c.Host)
+	req.Header.Set("User-Agent", c.UserAgent)
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Error executing request: %v", err)
+	}
+
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading response: %v", err)
+	}
+
+	c.recordQuota(res.Header)
+
+	if err := checkStatus(res.StatusCode, body); err != nil {
+		return nil, err
+	}
+	if err := checkErrors(body); err != nil {
+		if errors.Is(err, ErrQuotaExceeded) {
+			c.exhaustQuota()
+		}
+		return nil, err
+	}
+
+	return body, nil
+}
+
+// Saves the quota from the rate limit headers of a response
+func (c *Client) recordQuota(header http.Header) {
+	if c.QuotaPath == "" {
+		return
+	}
+
+	if quota, ok := quotaFromHeaders(header); ok {
+		SaveQuota(c.QuotaPath, quota)
+	}
+}
+
+// Marks the saved quota as used up when the API reports it is exceeded
+func (c *Client) exhaustQuota() {
+	if c.QuotaPath == "" {
+		return
+	}
+
+	quota, _ := LoadQuota(c.QuotaPath)
+	quota.Remaining = 0
+	quota.UpdatedAt = time.Now()
+	SaveQuota(c.QuotaPath, quota)
+}
+
+// Returns a TTL function that always gives the same duration
+func fixedTTL(ttl time.Duration) func() time.Duration {
+	return func() time.Duration { return ttl }
+}
+
+// Works out how long a list of fixtures can be cached. Finished fixtures never
+// change, live ones change every few seconds and upcoming ones are kept until
+// the next kickoff. Postponed fixtures and those without a kickoff time keep
+// their old date, so they are treated as upcoming whatever it says.
+func fixturesTTL(matches []Match) time.Duration {
+	ttl := Forever
+	now := time.Now()
+
+	for _, match := range matches {
+		switch {
+		case IsLive(match.Fixture.Status.Short):
+			return TTLLive
+		case IsFinished(match.Fixture.Status.Short):
+			continue
+		}
+
+		untilKickoff := TTLUpcoming
+		if kickoff := match.Kickoff(); !kickoff.IsZero() && !isUnscheduled(match.Fixture.Status.Short) {
+			untilKickoff = kickoff.Sub(now)
+		}
+		if untilKickoff < TTLLive {
+			return TTLLive
+		}
+		if untilKickoff > TTLUpcoming {
+			untilKickoff = TTLUpcoming
+		}
+		if ttl == Forever || untilKickoff < ttl {
+			ttl = untilKickoff
+		}
+	}
+
+	return ttl
+}
+
+// Checks if a fixture status short code means the match has no kickoff time,
+// having been postponed or not yet scheduled
+func isUnscheduled(status string) bool {
+	return status == "PST" || status == "TBD"
+}
+
+// Checks if a fixture status short code means the match is being played
+func IsLive(status string) bool {
+	switch status {
+	case "1H", "HT", "2H", "ET", "BT", "P", "SUSP", "INT", "LIVE":
+		return true
+	}
+	return false
+}
+
+// Checks if a fixture status short code means the match is over
+func IsFinished(status string) bool {
+	switch status {
+	case "FT", "AET", "PEN", "CANC", "ABD", "AWD", "WO":
+		return true
+	}
+	return false
+}
+
+// Gets the standings for a league and season
+func (c *Client) Standings(league, season int) ([]Standings, error) {
+	params := url.Values{}
+	params.Set("league", strconv.Itoa(league))
+	params.Set("season", strconv.Itoa(season))
+
+	var responseData ApiResponseStandings
+	if err := c.get("standings", params, &responseData, fixedTTL(TTLStandings)); err != nil {
+		return nil, err
+	}
+
+	return responseData.Response, nil
+}
+
+// Gets the current round name for a league and season
+func (c *Client) CurrentRound(league, season int) (string, error) {
+	params := url.Values{}
+	params.Set("league", strconv.Itoa(league))
+	params.Set("season", strconv.Itoa(season))
+	params.Set("current", "true")
+
+	var responseData ApiResponseRounds
+	if err := c.get("fixtures/rounds", params, &responseData, fixedTTL(TTLRounds)); err != nil {
+		return "", err
+	}
+
+	if len(responseData.Response) == 0 {
+		return "", fmt.Errorf("No round information found in the API response")
+	}
+
+	return responseData.Response[0], nil
+}
+
+// Gets the names of every round of a league and season, in order
+func (c *Client) Rounds(league, season int) ([]string, error) {
+	params := url.Values{}
+	params.Set("league", strconv.Itoa(league))
+	params.Set("season", strconv.Itoa(season))
+
+	var responseData ApiResponseRounds
+	if err := c.get("fixtures/rounds", params, &responseData, fixedTTL(TTLRounds)); err != nil {
+		return nil, err
+	}
+
+	return responseData.Response, nil
+}
+
+// Gets the fixtures of a round, or of the whole season if round is empty,
+// with kickoff times in the given timezone
+func (c *Client) Fixtures(league, season int, round, timezone string) ([]Match, error) {
+	params := url.Values{}
+	params.Set("league", strconv.Itoa(league))
+	params.Set("season", strconv.Itoa(season))
+	if round != "" {
+		params.Set("round", round)
+	}
+	if timezone != "" {
+		params.Set("timezone", timezone)
+	}
+
+	var responseData ApiResponseFixture
+	if err := c.get("fixtures", params, &responseData, func() time.Duration {
+		return fixturesTTL(responseData.Response)
+	}); err != nil {
+		return nil, err
+	}
+
+	return responseData.Response, nil
+}
+
+// Gets a single fixture given its fixture ID
+func (c *Client) FixtureByID(fixtureID int) (Match, error) {
+	params := url.Values{}
+	params.Set("id", strconv.Itoa(fixtureID))
+
+	var responseData ApiResponseFixture
+	if err := c.get("fixtures", params, &responseData, func() time.Duration {
+		return fixturesTTL(responseData.Response)
+	}); err != nil {
+		return Match{}, err
+	}
+
+	if len(responseData.Response) == 0 {
+		return Match{}, &Error{Kind: KindNotFound, Message: fmt.Sprintf("No fixture with ID %d.", fixtureID)}
+	}
+
+	return responseData.Response[0], nil
+}
+
+// Gets the lineups of both teams given a fixture ID
+func (c *Client) Lineups(fixtureID int) ([]Lineup, error) {
+	params := url.Values{}
+	params.Set("fixture", strconv.Itoa(fixtureID))
+
+	var responseData ApiResponseLineups
+	err := c.get("fixtures/lineups", params, &responseData, func() time.Duration {
+		if len(responseData.Response) == 0 {
+			return TTLLineupsPending
+		}
+		return Forever
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return responseData.Response, nil
+}
+
+// Gets the teams taking part in a league and season
+func (c *Client) Teams(league, season int) ([]TeamInfo, error) {
+	params := url.Values{}
+	params.Set("league", strconv.Itoa(league))
+	params.Set("season", strconv.Itoa(season))
+
+	var responseData ApiResponseTeams
+	if err := c.get("teams", params, &responseData, fixedTTL(TTLTeams)); err != nil {
+		return nil, err
+	}
+
+	return responseData.Response, nil
+}
+
+// Searches the leagues and cups by name or country, or lists all of them if
+// search is empty
+func (c *Client) Leagues(search string) ([]LeagueInfo, error) {
+	params := url.Values{}
+	if search != "" {
+		params.Set("search", search)
+	}
+
+	var responseData ApiResponseLeagues
+	if err := c.get("leagues", params, &responseData, fixedTTL(TTLLeagues)); err != nil {
+		return nil, err
+	}
+
+	return responseData.Response, nil
+}
+
+// Gets a league or cup given its league ID
+func (c *Client) League(leagueID int) (LeagueInfo, error) {
+	params := url.Values{}
+	params.Set("id", strconv.Itoa(leagueID))
+
+	var responseData ApiResponseLeagues
+	if err := c.get("leagues", params, &responseData, fixedTTL(TTLLeagues)); err != nil {
+		return LeagueInfo{}, err
+	}
+
+	if len(responseData.Response) == 0 {
+		return LeagueInfo{}, &Error{Kind: KindNotFound, Message: fmt.Sprintf("No league with ID %d.", leagueID)}
+	}
+
+	return responseData.Response[0], nil
+}
@@ -0,0 +1,61 @@
+/*
+Tests how long fixtures are cached for, from their status and kickoff time.
+*/
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+// Creates a fixture with a status and kickoff time, without one if zero
+func testMatch(status string, kickoff time.Time) Match {
+	var match Match
+	match.Fixture.Status.Short = status
+	if !kickoff.IsZero() {
+		match.Fixture.Date = kickoff.Format(time.RFC3339)
+	}
+	return match
+}
+
+func TestFixturesTTL(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		matches []Match
+		want    time.Duration
+	}{
+		{"no fixtures", nil, Forever},
+		{"finished", []Match{testMatch("FT", now.Add(-48*time.Hour)), testMatch("PEN", now.Add(-24*time.Hour))}, Forever},
+		{"cancelled", []Match{testMatch("CANC", now.Add(-24*time.Hour))}, Forever},
+		{"live", []Match{testMatch("FT", now.Add(-24*time.Hour)), testMatch("2H", now.Add(-time.Hour))}, TTLLive},
+		{"kickoff passed", []Match{testMatch("NS", now.Add(-5*time.Minute))}, TTLLive},
+		{"kickoff far off", []Match{testMatch("NS", now.Add(48*time.Hour))}, TTLUpcoming},
+		{"kickoff unknown", []Match{testMatch("TBD", time.Time{})}, TTLUpcoming},
+		{"postponed", []Match{testMatch("FT", now.Add(-48*time.Hour)), testMatch("PST", now.AddDate(0, -1, 0))}, TTLUpcoming},
+		{"to be decided", []Match{testMatch("TBD", now.Add(-24*time.Hour))}, TTLUpcoming},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := fixturesTTL(test.matches); got != test.want {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFixturesTTLUntilNextKickoff(t *testing.T) {
+	now := time.Now()
+	matches := []Match{
+		testMatch("FT", now.Add(-24*time.Hour)),
+		testMatch("NS", now.Add(40*time.Minute)),
+		testMatch("NS", now.Add(20*time.Minute)),
+	}
+
+	got := fixturesTTL(matches)
+	if got > 20*time.Minute || got < 19*time.Minute {
+		t.Errorf("got %v, want about 20m", got)
+	}
+}
@@ -0,0 +1,170 @@
+/*
+Typed errors for failed API-FOOTBALL requests. Errors are detected from the
+HTTP status code and from the "errors" object in the response body.
+*/
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+type ErrorKind int
+
+const (
+	KindInvalidKey ErrorKind = iota + 1
+	KindQuotaExceeded
+	KindRateLimited
+	KindNotFound
+	KindBadRequest
+	KindUpstream
+)
+
+// Sentinel errors to compare against with errors.Is
+var (
+	ErrInvalidKey    = &Error{Kind: KindInvalidKey}
+	ErrQuotaExceeded = &Error{Kind: KindQuotaExceeded}
+	ErrRateLimited   = &Error{Kind: KindRateLimited}
+	ErrNotFound      = &Error{Kind: KindNotFound}
+	ErrBadRequest    = &Error{Kind: KindBadRequest}
+	ErrUpstream      = &Error{Kind: KindUpstream}
+)
+
+// Error is returned when API-FOOTBALL rejects a request
+type Error struct {
+	Kind       ErrorKind
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	summary := ""
+	switch e.Kind {
+	case KindInvalidKey:
+		summary = "Invalid API key. Run 'premcli config --overwrite' to set a new key."
+	case KindQuotaExceeded:
+		summary = "Daily request quota exceeded. The quota resets at 00:00 UTC."
+	case KindRateLimited:
+		summary = "Rate limited by the API. Wait a minute and try again."
+	case KindNotFound:
+		summary = "Not found."
+	case KindBadRequest:
+		summary = "The API rejected the request."
+	case KindUpstream:
+		summary = "API-FOOTBALL is currently unavailable. Try again later."
+	default:
+		summary = "Unknown API error."
+	}
+
+	if e.StatusCode != 0 {
+		summary = fmt.Sprintf("%s (HTTP %d)", summary, e.StatusCode)
+	}
+	if e.Message != "" {
+		summary += " " + e.Message
+	}
+	return summary
+}
+
+// Matches errors of the same kind so the sentinels work with errors.Is
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Kind == e.Kind
+}
+
+// Process exit code for the error, distinct for every kind
+func (e *Error) ExitCode() int {
+	switch e.Kind {
+	case KindInvalidKey:
+		return 3
+	case KindQuotaExceeded:
+		return 4
+	case KindRateLimited:
+		return 5
+	case KindNotFound:
+		return 6
+	case KindBadRequest:
+		return 7
+	case KindUpstream:
+		return 8
+	}
+	return 1
+}
+
+// Maps a non 2xx status code to an error
+func checkStatus(statusCode int, body []byte) error {
+	if statusCode >= 200 && statusCode < 300 {
+		return nil
+	}
+
+	// RapidAPI wraps its own failures in {"message": "..."}
+	var payload struct {
+		Message string `json:"message"`
+	}
+	json.Unmarshal(body, &payload)
+	message := payload.Message
+
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return &Error{Kind: KindInvalidKey, StatusCode: statusCode, Message: message}
+	case statusCode == http.StatusTooManyRequests:
+		if strings.Contains(strings.ToLower(message), "quota") {
+			return &Error{Kind: KindQuotaExceeded, StatusCode: statusCode, Message: message}
+		}
+		return &Error{Kind: KindRateLimited, StatusCode: statusCode, Message: message}
+	case statusCode == http.StatusNotFound:
+		return &Error{Kind: KindNotFound, StatusCode: statusCode, Message: message}
+	case statusCode >= 500:
+		return &Error{Kind: KindUpstream, StatusCode: statusCode, Message: message}
+	}
+
+	return &Error{Kind: KindBadRequest, StatusCode: statusCode, Message: message}
+}
+
+// Decodes the "errors" field of a response. API-FOOTBALL sends an empty array
+// when there are no errors and an object keyed by field otherwise.
+func checkErrors(body []byte) error {
+	var envelope struct {
+		Errors json.RawMessage `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil || len(envelope.Errors) == 0 {
+		return nil
+	}
+
+	errorsByKey := map[string]string{}
+	if err := json.Unmarshal(envelope.Errors, &errorsByKey); err != nil {
+		var list []string
+		if json.Unmarshal(envelope.Errors, &list) != nil || len(list) == 0 {
+			return nil
+		}
+		return &Error{Kind: KindBadRequest, Message: strings.Join(list, " ")}
+	}
+	if len(errorsByKey) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(errorsByKey))
+	for key := range errorsByKey {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var messages []string
+	for _, key := range keys {
+		messages = append(messages, errorsByKey[key])
+	}
+	message := strings.Join(messages, " ")
+
+	switch {
+	case errorsByKey["token"] != "":
+		return &Error{Kind: KindInvalidKey, Message: message}
+	case errorsByKey["requests"] != "":
+		return &Error{Kind: KindQuotaExceeded, Message: message}
+	case errorsByKey["rateLimit"] != "":
+		return &Error{Kind: KindRateLimited, Message: message}
+	}
+
+	return &Error{Kind: KindBadRequest, Message: message}
+}
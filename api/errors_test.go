@@ -0,0 +1,109 @@
+/*
+Tests that failed requests come back as the right kind of error, from the HTTP
+status code or from the "errors" object of a successful response.
+*/
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Creates a Client that sends its requests to handler
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := NewClient("test")
+	client.BaseURL = server.URL
+	return client
+}
+
+// Creates a handler that always responds with the status code and body
+func respondWith(statusCode int, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		w.Write([]byte(body))
+	}
+}
+
+func TestCheckStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		want       error
+	}{
+		{"unauthorised", http.StatusUnauthorized, `{"message": "Invalid API key."}`, ErrInvalidKey},
+		{"forbidden", http.StatusForbidden, `{"message": "You are not subscribed to this API."}`, ErrInvalidKey},
+		{"quota", http.StatusTooManyRequests, `{"message": "You have exceeded the DAILY quota for Requests on your current plan"}`, ErrQuotaExceeded},
+		{"rate limited", http.StatusTooManyRequests, `{"message": "Too many requests"}`, ErrRateLimited},
+		{"not found", http.StatusNotFound, `{"message": "Endpoint '/standing' does not exist"}`, ErrNotFound},
+		{"server error", http.StatusInternalServerError, `{"message": "Internal error"}`, ErrUpstream},
+		{"bad gateway without a body", http.StatusBadGateway, ``, ErrUpstream},
+		{"bad request", http.StatusBadRequest, `{"message": "Bad request"}`, ErrBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := newTestClient(t, respondWith(test.statusCode, test.body))
+
+			_, err := client.Standings(39, 2024)
+			if !errors.Is(err, test.want) {
+				t.Fatalf("got %v, want %v", err, test.want)
+			}
+
+			var apiErr *Error
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != test.statusCode {
+				t.Errorf("got status code %d, want %d", apiErr.StatusCode, test.statusCode)
+			}
+		})
+	}
+}
+
+func TestCheckErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want error
+	}{
+		{"no errors", `{"errors": [], "response": []}`, nil},
+		{"empty object", `{"errors": {}, "response": []}`, nil},
+		{"no errors field", `{"response": []}`, nil},
+		{"token", `{"errors": {"token": "Error/Missing application key."}, "response": []}`, ErrInvalidKey},
+		{"requests", `{"errors": {"requests": "You have reached the request limit for the day."}, "response": []}`, ErrQuotaExceeded},
+		{"rate limit", `{"errors": {"rateLimit": "Too many requests."}, "response": []}`, ErrRateLimited},
+		{"field", `{"errors": {"season": "The Season field must contain 4 digits."}, "response": []}`, ErrBadRequest},
+		{"list", `{"errors": ["Something went wrong."], "response": []}`, ErrBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := newTestClient(t, respondWith(http.StatusOK, test.body))
+
+			_, err := client.Standings(39, 2024)
+			if test.want == nil {
+				if err != nil {
+					t.Fatalf("got %v, want no error", err)
+				}
+				return
+			}
+			if !errors.Is(err, test.want) {
+				t.Fatalf("got %v, want %v", err, test.want)
+			}
+		})
+	}
+}
+
+func TestCheckErrorsMessage(t *testing.T) {
+	body := []byte(`{"errors": {"season": "The Season field is required.", "league": "The League field is required."}}`)
+
+	err := checkErrors(body)
+	want := "The API rejected the request. The League field is required. The Season field is required."
+	if err == nil || err.Error() != want {
+		t.Errorf("got %v, want %q", err, want)
+	}
+}
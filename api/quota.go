@@ -0,0 +1,93 @@
+/*
+Tracks the daily request quota reported by the x-ratelimit-requests-* headers.
+*/
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+type Quota struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Number of requests used since the quota last reset
+func (q Quota) Used() int {
+	return q.Limit - q.Remaining
+}
+
+// The quota resets every day at 00:00 UTC
+func (q Quota) ResetsAt() time.Time {
+	updated := q.UpdatedAt.UTC()
+	return time.Date(updated.Year(), updated.Month(), updated.Day()+1, 0, 0, 0, 0, time.UTC)
+}
+
+// Checks if the quota was recorded since the last reset
+func (q Quota) Current() bool {
+	return !q.UpdatedAt.IsZero() && time.Now().Before(q.ResetsAt())
+}
+
+// Reads the quota from the rate limit headers of a response
+func quotaFromHeaders(header http.Header) (Quota, bool) {
+	limit, err := strconv.Atoi(header.Get("x-ratelimit-requests-limit"))
+	if err != nil {
+		return Quota{}, false
+	}
+	remaining, err := strconv.Atoi(header.Get("x-ratelimit-requests-remaining"))
+	if err != nil {
+		return Quota{}, false
+	}
+
+	return Quota{Limit: limit, Remaining: remaining, UpdatedAt: time.Now()}, true
+}
+
+// Loads the last recorded quota. Returns false if none has been recorded yet.
+func LoadQuota(path string) (Quota, bool) {
+	var quota Quota
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return quota, false
+	}
+	if err := json.Unmarshal(data, &quota); err != nil {
+		return quota, false
+	}
+
+	return quota, true
+}
+
+// Saves the quota so later runs can check it before making requests
+func SaveQuota(path string, quota Quota) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(quota)
+	if err != nil {
+		return err
+	}
+
+	// Write then rename as several requests may finish at the same time
+	tmp, err := os.CreateTemp(filepath.Dir(path), "quota-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,238 @@
+/*
+Response types returned by the API-FOOTBALL endpoints.
+*/
+package api
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ApiResponseFixture struct {
+	Response []Match `json:"response"`
+}
+
+type Match struct {
+	Fixture struct {
+		ID    int
+		Date  string
+		Venue struct {
+			Name string
+			City string
+		}
+		Status struct {
+			Short   string
+			Elapsed int
+		}
+	}
+	League struct {
+		ID     int
+		Season int
+		// e.g. "Regular Season - 12"
+		Round string
+	}
+	Teams struct {
+		Home struct {
+			ID   int
+			Name string
+		}
+		Away struct {
+			ID   int
+			Name string
+		}
+	}
+	Goals struct {
+		Home int
+		Away int
+	}
+	// Only included when a fixture is requested by ID
+	Events     []Events
+	Statistics []TeamStatistics
+}
+
+// Kickoff time of the fixture, zero if the date can't be parsed
+func (m Match) Kickoff() time.Time {
+	kickoff, err := time.Parse(time.RFC3339, m.Fixture.Date)
+	if err != nil {
+		return time.Time{}
+	}
+	return kickoff
+}
+
+type ApiResponseRounds struct {
+	Response []string `json:"response"`
+}
+
+type Events struct {
+	Time struct {
+		Elapsed int
+		Extra   int
+	}
+	Team struct {
+		ID   int
+		Name string
+	}
+	Player struct {
+		Name string
+	}
+	Assist struct {
+		Name string
+	}
+	Type     string
+	Detail   string
+	Comments string
+}
+
+type ApiResponseLineups struct {
+	Response []Lineup `json:"response"`
+}
+
+type Lineup struct {
+	Team struct {
+		ID   int
+		Name string
+	}
+	Formation   string
+	StartXI     []LineupPlayer `json:"startXI"`
+	Substitutes []LineupPlayer
+	Coach       struct {
+		Name string
+	}
+}
+
+type LineupPlayer struct {
+	Player struct {
+		ID     int
+		Name   string
+		Number int
+		Pos    string
+		// Position on the pitch as "row:column", row 1 being the goalkeeper
+		Grid string
+	}
+}
+
+type TeamStatistics struct {
+	Team struct {
+		ID   int
+		Name string
+	}
+	Statistics []Statistic
+}
+
+// A single statistic. Value is a number, a string such as "55%" or "1.23", or null.
+type Statistic struct {
+	Type  string
+	Value interface{}
+}
+
+// Gets the value of a statistic as a number, false if it isn't available
+func (s Statistic) Number() (float64, bool) {
+	switch value := s.Value.(type) {
+	case float64:
+		return value, true
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		return number, err == nil
+	}
+	return 0, false
+}
+
+// Finds a statistic by its type, e.g. "Ball Possession"
+func (t TeamStatistics) Get(statType string) (Statistic, bool) {
+	for _, statistic := range t.Statistics {
+		if statistic.Type == statType {
+			return statistic, true
+		}
+	}
+	return Statistic{}, false
+}
+
+type ApiResponseStandings struct {
+	Response []Standings `json:"response"`
+}
+
+type Standings struct {
+	League struct {
+		ID     int
+		Name   string
+		Season int
+		// One table per group, a league has a single group
+		Standings [][]Standing
+	}
+}
+
+type Standing struct {
+	Rank int
+	Team struct {
+		ID   int
+		Name string
+	}
+	Points    int
+	GoalsDiff int
+	// e.g. "Group A", or the league name when there are no groups
+	Group string
+	Form  string
+	// Qualification or relegation zone, e.g. "Promotion - Champions League"
+	Description string
+	All         Record
+	Home        Record
+	Away        Record
+}
+
+// Results over all matches, or only home or away ones
+type Record struct {
+	Played int
+	Win    int
+	Draw   int
+	Lose   int
+	Goals  struct {
+		For     int
+		Against int
+	}
+}
+
+func (r Record) Points() int {
+	return 3*r.Win + r.Draw
+}
+
+func (r Record) GoalDifference() int {
+	return r.Goals.For - r.Goals.Against
+}
+
+type ApiResponseTeams struct {
+	Response []TeamInfo `json:"response"`
+}
+
+type TeamInfo struct {
+	Team struct {
+		ID      int
+		Name    string
+		Code    string
+		Country string
+	}
+	Venue struct {
+		Name string
+		City string
+	}
+}
+
+type ApiResponseLeagues struct {
+	Response []LeagueInfo `json:"response"`
+}
+
+type LeagueInfo struct {
+	League struct {
+		ID   int
+		Name string
+		// "League" or "Cup"
+		Type string
+	}
+	Country struct {
+		Name string
+	}
+	Seasons []struct {
+		Year    int
+		Current bool
+	}
+}
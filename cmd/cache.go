@@ -0,0 +1,59 @@
+/*
+Manages the on-disk cache of API responses kept in ~/.cache/premcli.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"premcli/api"
+
+	"github.com/spf13/cobra"
+)
+
+var cachePath = filepath.Join(os.Getenv("HOME"), ".cache", "premcli")
+var responseCachePath = filepath.Join(cachePath, "responses")
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect or clear the API response cache",
+	Long: `Inspect or clear the API response cache.
+
+API responses are cached in ~/.cache/premcli to save requests from the daily quota.
+Rounds and standings are kept for a few hours, finished fixtures forever and live fixtures for seconds.`,
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show the number and size of cached responses",
+	Run: func(cmd *cobra.Command, args []string) {
+		stats, err := api.NewCache(responseCachePath).Stats()
+		if err != nil {
+			exitWithError("Error reading cache:", err)
+		}
+
+		fmt.Printf("Location: %s\n", responseCachePath)
+		fmt.Printf("Entries:  %d (%d expired)\n", stats.Entries, stats.Expired)
+		fmt.Printf("Size:     %.1f KB\n", float64(stats.Bytes)/1024)
+	},
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove every cached response",
+	Run: func(cmd *cobra.Command, args []string) {
+		removed, err := api.NewCache(responseCachePath).Clear()
+		if err != nil {
+			exitWithError("Error clearing cache:", err)
+		}
+
+		fmt.Printf("Removed %d cached responses.\n", removed)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheStatsCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+}
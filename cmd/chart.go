@@ -0,0 +1,125 @@
+/*
+Draws league positions over a season as a line chart in the terminal, one line
+per team with position 1 at the top.
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// A team drawn on the chart, with its position after each round. A position
+// of 0 means the team wasn't in the table after that round.
+type chartLine struct {
+	Name      string
+	Positions []int
+}
+
+// Colours and markers of the lines, the markers telling them apart without colour
+var (
+	chartColors  = []color.Attribute{color.FgMagenta, color.FgCyan, color.FgYellow, color.FgGreen, color.FgRed, color.FgBlue}
+	chartMarkers = []rune{'●', '■', '▲', '◆', '★', '✚'}
+)
+
+// Columns each round takes up on the chart
+const chartRoundWidth = 3
+
+type chartCell struct {
+	r     rune
+	color *color.Color
+}
+
+// Draws the position of each line after every round. Positions run from 1 at
+// the top to places at the bottom, and moves between rounds are joined by
+// steps so lines can be followed across the chart.
+func renderPositionChart(lines []chartLine, rounds, places int) string {
+	width := rounds * chartRoundWidth
+	grid := make([][]chartCell, places)
+	for i := range grid {
+		grid[i] = make([]chartCell, width)
+	}
+
+	set := func(position, col int, r rune, c *color.Color) {
+		if position >= 1 && position <= places && col >= 0 && col < width {
+			grid[position-1][col] = chartCell{r, c}
+		}
+	}
+
+	// Lines first so the markers are drawn over them
+	for i, line := range lines {
+		c := color.New(chartColors[i%len(chartColors)])
+		for round := 0; round+1 < len(line.Positions) && round+1 < rounds; round++ {
+			from, to := line.Positions[round], line.Positions[round+1]
+			if from == 0 || to == 0 {
+				continue
+			}
+
+			col := round*chartRoundWidth + 1
+			set(from, col, '─', c)
+			switch {
+			case from == to:
+				set(from, col+1, '─', c)
+			case from < to:
+				set(from, col+1, '╮', c)
+				for position := from + 1; position < to; position++ {
+					set(position, col+1, '│', c)
+				}
+				set(to, col+1, '╰', c)
+			default:
+				set(from, col+1, '╯', c)
+				for position := to + 1; position < from; position++ {
+					set(position, col+1, '│', c)
+				}
+				set(to, col+1, '╭', c)
+			}
+		}
+	}
+
+	for i, line := range lines {
+		c := color.New(chartColors[i%len(chartColors)])
+		for round, position := range line.Positions {
+			if round < rounds {
+				set(position, round*chartRoundWidth, chartMarkers[i%len(chartMarkers)], c)
+			}
+		}
+	}
+
+	var b strings.Builder
+	for i, row := range grid {
+		fmt.Fprintf(&b, "%3d ┤", i+1)
+		for _, cell := range row {
+			switch {
+			case cell.r == 0:
+				b.WriteRune(' ')
+			case cell.color != nil:
+				b.WriteString(cell.color.Sprint(string(cell.r)))
+			default:
+				b.WriteRune(cell.r)
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	// Round numbers along the bottom, every fifth round and the last
+	b.WriteString("    └" + strings.Repeat("─", width) + "\n")
+	axis := []rune(strings.Repeat(" ", width+chartRoundWidth))
+	for round := 1; round <= rounds; round++ {
+		if round != 1 && round%5 != 0 && round != rounds {
+			continue
+		}
+		label := []rune(fmt.Sprint(round))
+		copy(axis[(round-1)*chartRoundWidth:], label)
+	}
+	b.WriteString("     " + strings.TrimRight(string(axis), " ") + "\n")
+
+	b.WriteString("\n")
+	for i, line := range lines {
+		c := color.New(chartColors[i%len(chartColors)])
+		fmt.Fprintf(&b, "%s %s\n", c.Sprint(string(chartMarkers[i%len(chartMarkers)])), line.Name)
+	}
+
+	return b.String()
+}
@@ -3,6 +3,7 @@ Sets up premcli.conf file for the user
 Collects information such as:
 - API Key
 - TimeZone
+- League
 - Favourite Team
 */
 package cmd
@@ -45,6 +46,55 @@ func CreateDir() bool {
 	return true
 }
 
+// Asks for the league until it is a valid ID or alias. An empty answer keeps
+// the Premier League.
+func readLeague(reader *bufio.Reader) {
+	for {
+		fmt.Print("Enter your league (default epl): ")
+		answer, readErr := reader.ReadString('\n')
+
+		id, err := parseLeague(answer)
+		if err == nil {
+			league = id
+			return
+		}
+		fmt.Println(err)
+
+		// Keep the default once the input runs out
+		if readErr != nil {
+			return
+		}
+	}
+}
+
+// Asks for the favourite team until it resolves to a single team and returns
+// its code. If the teams can't be loaded, e.g. because of a typo in the API key,
+// the answer is saved as typed.
+func readFavTeam(reader *bufio.Reader, key string) string {
+	client := newClient()
+	client.Key = key
+	loadErr := loadTeams(client)
+	if loadErr != nil {
+		fmt.Println("Couldn't load the teams to check your answer:", loadErr)
+	}
+
+	for {
+		fmt.Print("Enter your favourite team: ")
+		answer, err := reader.ReadString('\n')
+		answer = strings.TrimSpace(answer)
+		if loadErr != nil || answer == "" || err != nil {
+			return answer
+		}
+
+		t, resolveErr := resolveTeam(answer)
+		if resolveErr == nil {
+			fmt.Printf("Favourite team set to %s (%s)\n", t.Name, t.Code)
+			return t.Code
+		}
+		fmt.Println(resolveErr)
+	}
+}
+
 var configCmd = &cobra.Command{
 	Use:   "config",
 	Short: "Configure settings for premcli",
@@ -62,7 +112,7 @@ var configCmd = &cobra.Command{
 		// Return if premcli.conf exists
 		if ConfigExists() {
 			fmt.Println("Config file already exists at", configPath)
-			fmt.Println("Use the -overwrite flag to replace it.")
+			fmt.Println("Use the --overwrite flag to replace it.")
 			return
 		}
 
@@ -80,12 +130,13 @@ var configCmd = &cobra.Command{
 		timezone, _ := reader.ReadString('\n')
 		timezone = strings.TrimSpace(timezone)
 
-		fmt.Print("TEAM EXAMPLE: WOL, MCI, CHE\n")
-		fmt.Print("Enter your Premier League team: ")
-		favTeam, _ := reader.ReadString('\n')
-		favTeam = strings.TrimSpace(favTeam)
+		fmt.Print("LEAGUE EXAMPLE: epl, championship, laliga, ucl or an ID from 'premcli leagues'\n")
+		readLeague(reader)
+
+		fmt.Print("TEAM EXAMPLE: WOL, Man City, Spurs\n")
+		favTeam := readFavTeam(reader, apiKey)
 
-		content := fmt.Sprintf("API_KEY=%s\nTIMEZONE=%s\nFAVTEAM=%s\n", apiKey, timezone, favTeam)
+		content := fmt.Sprintf("API_KEY=%s\nTIMEZONE=%s\nFAVTEAM=%s\nLEAGUE=%d\n", apiKey, timezone, favTeam, league)
 
 		if !CreateDir() {
 			return
@@ -104,5 +155,5 @@ var configCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(configCmd)
-	configCmd.Flags().BoolVarP(&overwrite, "overwrite", "o", false, "Overwrite the existing config")
+	configCmd.Flags().BoolVar(&overwrite, "overwrite", false, "Overwrite the existing config")
 }
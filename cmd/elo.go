@@ -0,0 +1,372 @@
+/*
+Elo ratings worked out from every finished fixture in the local store, carried
+from one season to the next. Home teams get a head start and bigger wins move
+ratings further.
+*/
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"premcli/api"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	// Rating of every team in the first stored season
+	eloStart = 1500
+	// Rating of teams joining the league in a later season, most of them
+	// promoted from the division below
+	eloNewTeam = 1450
+	// Most points a rating moves after a one goal result
+	eloK = 20
+	// Points added to the home team's rating when working out chances
+	eloHomeAdvantage = 60
+	// Share of a team's distance from the average kept from one season to the
+	// next, as squads change over the summer
+	eloSeasonCarry = 0.8
+	// Chance of a draw between evenly matched teams
+	eloDrawRate = 0.28
+)
+
+var eloLast int
+
+// A team's rating before and after a match
+type eloChange struct {
+	Match  api.Match
+	Before float64
+	After  float64
+}
+
+type eloRatings struct {
+	Ratings map[int]float64
+	Names   map[int]string
+	History map[int][]eloChange
+}
+
+// Gets a team's rating, or the rating a new team would start on
+func (r *eloRatings) rating(teamID int) float64 {
+	if rating, exists := r.Ratings[teamID]; exists {
+		return rating
+	}
+	return eloNewTeam
+}
+
+// Gets the chance of the home team winning, the score of a draw counting half,
+// e.g. 0.5 between two teams rated the same on neutral ground
+func eloExpected(home, away float64) float64 {
+	return 1 / (1 + math.Pow(10, (away-home-eloHomeAdvantage)/400))
+}
+
+// Weights a result by its margin, so a three goal win counts 1.75 times as
+// much as a one goal win
+func eloMargin(goalDifference int) float64 {
+	margin := goalDifference
+	if margin < 0 {
+		margin = -margin
+	}
+	switch {
+	case margin <= 1:
+		return 1
+	case margin == 2:
+		return 1.5
+	}
+	return float64(11+margin) / 8
+}
+
+// Gets the chances of a home win, a draw and an away win. Draws are likeliest
+// between evenly matched teams, and each side keeps the expected score Elo
+// gives it.
+func (r *eloRatings) chances(match api.Match) (home, draw, away float64) {
+	expected := eloExpected(r.rating(match.Teams.Home.ID), r.rating(match.Teams.Away.ID))
+	draw = eloDrawRate * (1 - math.Abs(2*expected-1))
+	home = expected - draw/2
+	away = 1 - expected - draw/2
+	return home, draw, away
+}
+
+// Updates both teams' ratings with a finished match
+func (r *eloRatings) addMatch(match api.Match) {
+	homeID, awayID := match.Teams.Home.ID, match.Teams.Away.ID
+	home, away := r.rating(homeID), r.rating(awayID)
+
+	score := 0.5
+	switch {
+	case match.Goals.Home > match.Goals.Away:
+		score = 1
+	case match.Goals.Home < match.Goals.Away:
+		score = 0
+	}
+
+	change := eloK * eloMargin(match.Goals.Home-match.Goals.Away) * (score - eloExpected(home, away))
+	r.Ratings[homeID] = home + change
+	r.Ratings[awayID] = away - change
+	r.Names[homeID] = match.Teams.Home.Name
+	r.Names[awayID] = match.Teams.Away.Name
+	r.History[homeID] = append(r.History[homeID], eloChange{match, home, home + change})
+	r.History[awayID] = append(r.History[awayID], eloChange{match, away, away - change})
+}
+
+// Rates every team from the finished fixtures of each season in turn, oldest
+// first. Between seasons ratings drift back towards the average.
+func rateSeasons(stores []*seasonStore) *eloRatings {
+	ratings := &eloRatings{
+		Ratings: map[int]float64{},
+		Names:   map[int]string{},
+		History: map[int][]eloChange{},
+	}
+
+	for _, store := range stores {
+		// The first season with results has nothing to go on, so everyone
+		// starts level
+		first := len(ratings.Ratings) == 0
+		for id, rating := range ratings.Ratings {
+			ratings.Ratings[id] = eloStart + eloSeasonCarry*(rating-eloStart)
+		}
+
+		matches := append([]api.Match(nil), store.Matches...)
+		sortMatches(matches)
+		for _, match := range matches {
+			if !countsInTable(match.Fixture.Status.Short) {
+				continue
+			}
+			if first {
+				for _, id := range []int{match.Teams.Home.ID, match.Teams.Away.ID} {
+					if _, exists := ratings.Ratings[id]; !exists {
+						ratings.Ratings[id] = eloStart
+					}
+				}
+			}
+			ratings.addMatch(match)
+		}
+	}
+
+	return ratings
+}
+
+// Rates the teams of the followed league from every stored season up to the
+// chosen one. The chosen season's fixtures are fetched and stored first, so
+// looking at a past season adds it to the history. Also returns the chosen
+// season's fixtures.
+func loadEloRatings(client *api.Client) (*eloRatings, []api.Match, error) {
+	season := getSeason(client)
+
+	matches, err := client.Fixtures(league, season, "", timezone)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	current, err := loadStore(league, season)
+	if err != nil {
+		return nil, nil, err
+	}
+	current.addMatches(matches)
+	if len(current.Matches) > 0 {
+		if err := current.save(); err != nil {
+			fmt.Fprintln(os.Stderr, "Warning: couldn't save the store:", err)
+		}
+	}
+
+	seasons, err := storedSeasons(league)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var stores []*seasonStore
+	for _, stored := range seasons {
+		switch {
+		case stored > season:
+			continue
+		case stored == season:
+			stores = append(stores, current)
+			continue
+		}
+
+		store, err := loadStore(league, stored)
+		if err != nil {
+			return nil, nil, err
+		}
+		stores = append(stores, store)
+	}
+	// Seasons without results or whose store couldn't be saved aren't listed
+	if len(stores) == 0 || stores[len(stores)-1] != current {
+		stores = append(stores, current)
+	}
+
+	return rateSeasons(stores), matches, nil
+}
+
+// Formats a change in rating, e.g. "+12.3"
+func formatEloChange(change float64) string {
+	return fmt.Sprintf("%+.1f", change)
+}
+
+// Formats the chances of a fixture, e.g. "Wolves 45% | Draw 27% | Spurs 28%"
+func formatEloChances(ratings *eloRatings, match api.Match) string {
+	home, draw, away := ratings.chances(match)
+	return fmt.Sprintf("%s %.0f%% | Draw %.0f%% | %s %.0f%%", match.Teams.Home.Name, home*100, draw*100, match.Teams.Away.Name, away*100)
+}
+
+// Prints the teams of the season's fixtures by rating, with how much their
+// rating moved over their last five matches
+func printEloRanking(title string, ratings *eloRatings, matches []api.Match) {
+	printTableTitle(title)
+
+	seen := map[int]bool{}
+	var ids []int
+	for _, match := range matches {
+		for _, id := range []int{match.Teams.Home.ID, match.Teams.Away.ID} {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	sort.SliceStable(ids, func(i, j int) bool {
+		return ratings.rating(ids[i]) > ratings.rating(ids[j])
+	})
+
+	t := newTable("Rank", "Club", "Elo", "Matches", "Last 5")
+	for i, id := range ids {
+		paint := teamPainter(id, "")
+
+		name := ratings.Names[id]
+		if name == "" {
+			name = teamNameIn(matches, id)
+		}
+
+		history := ratings.History[id]
+		recent := "-"
+		if len(history) > 0 {
+			from := history[max(0, len(history)-5)].Before
+			recent = formatEloChange(history[len(history)-1].After - from)
+		}
+
+		t.AddRow(
+			paint(i+1),
+			paint(name),
+			paint(fmt.Sprintf("%.0f", ratings.rating(id))),
+			paint(len(history)),
+			paint(recent),
+		)
+	}
+
+	t.Render(os.Stdout)
+}
+
+// Gets a team's name from the fixtures it plays in
+func teamNameIn(matches []api.Match, teamID int) string {
+	for _, match := range matches {
+		if match.Teams.Home.ID == teamID {
+			return match.Teams.Home.Name
+		}
+		if match.Teams.Away.ID == teamID {
+			return match.Teams.Away.Name
+		}
+	}
+	return ""
+}
+
+// Prints how a team's rating changed with each match, the most recent last
+func printEloHistory(title string, history []eloChange, teamID int) {
+	printTableTitle(title)
+
+	t := newTable("Date", "Season", "Opponent", "Result", "Change", "Elo")
+	for _, change := range history {
+		match := change.Match
+		opponent := match.Teams.Away.Name + " (H)"
+		goalsFor, goalsAgainst := match.Goals.Home, match.Goals.Away
+		if match.Teams.Away.ID == teamID {
+			opponent = match.Teams.Home.Name + " (A)"
+			goalsFor, goalsAgainst = goalsAgainst, goalsFor
+		}
+
+		letter := resultLetter(goalsFor, goalsAgainst)
+		result := formColors[rune(letter[0])].Sprintf("%s %d-%d", letter, goalsFor, goalsAgainst)
+
+		t.AddRow(
+			match.Kickoff().Local().Format("02 Jan 2006"),
+			fmt.Sprint(match.League.Season),
+			opponent,
+			result,
+			formatEloChange(change.After-change.Before),
+			fmt.Sprintf("%.0f", change.After),
+		)
+	}
+
+	t.Render(os.Stdout)
+}
+
+var eloCmd = &cobra.Command{
+	Use:   "elo [team]",
+	Short: "Ranks teams by Elo rating or shows a team's rating history",
+	Long: `Ranks the teams of the followed league by an Elo rating worked out from every finished fixture in the local store, or shows how a team's rating changed match by match.
+
+Ratings carry over from one season to the next, so they get better as the store holds more seasons. Looking at a past season with --season adds it to the store. Home teams get a head start and bigger wins move ratings further.
+
+Use 'premcli fixtures --elo' to see the win, draw and loss chances of upcoming fixtures.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		// Get the config
+		err := GetConfig()
+		if err != nil {
+			exitWithError("Error loading config:", err)
+		}
+
+		// The season's fixtures
+		err = checkQuota(1 + extraRequests(true, len(args) > 0 || favTeam != ""))
+		if err != nil {
+			exitWithError("Not enough API quota:", err)
+		}
+
+		client := newClient()
+
+		ratings, matches, err := loadEloRatings(client)
+		if err != nil {
+			exitWithError("Error fetching and parsing:", err)
+		}
+
+		if len(args) == 0 {
+			loadFavTeam(client)
+			printEloRanking(fmt.Sprintf("%s Elo ratings", leagueName(client)), ratings, matches)
+			return
+		}
+
+		err = loadTeams(client)
+		if err != nil {
+			exitWithError("Error loading teams:", err)
+		}
+		t, err := resolveTeam(args[0])
+		if err != nil {
+			exitWithError("Invalid team:", err)
+		}
+
+		history := ratings.History[t.ID]
+		if len(history) == 0 {
+			exitWithError("Error getting rating:", fmt.Errorf("%s have no finished matches in the store", t.Name))
+		}
+		if eloLast > 0 && len(history) > eloLast {
+			history = history[len(history)-eloLast:]
+		}
+
+		printEloHistory(fmt.Sprintf("%s Elo rating history", t.Name), history, t.ID)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(eloCmd)
+
+	eloCmd.Flags().IntVar(&eloLast, "last", 0, "Only show a team's last few matches")
+
+	eloCmd.Example = ` # Rank the teams by rating
+premcli elo
+
+ # Show how Wolves' rating has changed
+premcli elo WOL
+
+ # Add the 2023 season to the store and rank the teams as they were then
+premcli elo --season 2023`
+}
@@ -0,0 +1,114 @@
+/*
+Tests Elo expected scores, rating updates and the chances they give, and that
+ratings carry over from one season to the next.
+*/
+package cmd
+
+import (
+	"math"
+	"premcli/api"
+	"testing"
+)
+
+func closeTo(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func newEloRatings() *eloRatings {
+	return &eloRatings{Ratings: map[int]float64{}, Names: map[int]string{}, History: map[int][]eloChange{}}
+}
+
+func TestEloExpected(t *testing.T) {
+	if got := eloExpected(1500, 1500+eloHomeAdvantage); !closeTo(got, 0.5) {
+		t.Errorf("got %v with the home advantage made up, want 0.5", got)
+	}
+	if got := eloExpected(1500, 1500); got <= 0.5 {
+		t.Errorf("got %v between level teams, want the home team favoured", got)
+	}
+
+	// On neutral ground the two teams' expected scores add up to 1
+	stronger, weaker := 1700.0, 1400.0
+	sum := eloExpected(stronger, weaker+eloHomeAdvantage) + eloExpected(weaker, stronger+eloHomeAdvantage)
+	if !closeTo(sum, 1) {
+		t.Errorf("got expected scores adding up to %v, want 1", sum)
+	}
+}
+
+func TestEloMargin(t *testing.T) {
+	tests := []struct {
+		goalDifference int
+		want           float64
+	}{
+		{0, 1},
+		{1, 1},
+		{-1, 1},
+		{2, 1.5},
+		{-3, 1.75},
+		{4, 1.875},
+	}
+
+	for _, test := range tests {
+		if got := eloMargin(test.goalDifference); !closeTo(got, test.want) {
+			t.Errorf("got %v for a margin of %d, want %v", got, test.goalDifference, test.want)
+		}
+	}
+}
+
+func TestEloChances(t *testing.T) {
+	ratings := newEloRatings()
+	ratings.Ratings[1], ratings.Ratings[2] = 1500, 1500+eloHomeAdvantage
+
+	home, draw, away := ratings.chances(testFixture(1, 2, "NS", 0, 0))
+	if !closeTo(home+draw+away, 1) {
+		t.Errorf("got chances adding up to %v, want 1", home+draw+away)
+	}
+	if !closeTo(home, away) || !closeTo(draw, eloDrawRate) {
+		t.Errorf("got %v, %v, %v between evenly matched teams", home, draw, away)
+	}
+
+	// A new team is rated below the others
+	home, draw, away = ratings.chances(testFixture(1, 3, "NS", 0, 0))
+	if !closeTo(home+draw+away, 1) || home <= away || draw >= eloDrawRate {
+		t.Errorf("got %v, %v, %v against a new team", home, draw, away)
+	}
+}
+
+func TestEloAddMatch(t *testing.T) {
+	ratings := newEloRatings()
+	ratings.Ratings[1], ratings.Ratings[2] = 1500, 1500
+
+	ratings.addMatch(testFixture(2, 1, "FT", 0, 3))
+	if !closeTo(ratings.Ratings[1]+ratings.Ratings[2], 3000) {
+		t.Errorf("got ratings %v and %v, want them to add up to 3000", ratings.Ratings[1], ratings.Ratings[2])
+	}
+
+	// An away win by three counts 1.75 times a one goal win
+	want := eloK * 1.75 * (1 - (1 - eloExpected(1500, 1500)))
+	if got := ratings.Ratings[1] - 1500; !closeTo(got, want) {
+		t.Errorf("the winner gained %v, want %v", got, want)
+	}
+	if len(ratings.History[1]) != 1 || ratings.History[1][0].Before != 1500 {
+		t.Errorf("got history %v", ratings.History[1])
+	}
+}
+
+func TestRateSeasons(t *testing.T) {
+	first := &seasonStore{Matches: []api.Match{testFixture(1, 2, "FT", 2, 0), testFixture(2, 1, "NS", 0, 0)}}
+	second := &seasonStore{Matches: []api.Match{testFixture(1, 3, "FT", 1, 1)}}
+
+	ratings := rateSeasons([]*seasonStore{first, second})
+
+	// Alpha's rating after the first season drifts back towards the average
+	history := ratings.History[1]
+	if len(history) != 2 {
+		t.Fatalf("got %d rated matches for Alpha, want 2", len(history))
+	}
+	if want := eloStart + eloSeasonCarry*(history[0].After-eloStart); !closeTo(history[1].Before, want) {
+		t.Errorf("Alpha started the second season on %v, want %v", history[1].Before, want)
+	}
+
+	// Gamma joined in the second season
+	if got := ratings.History[3][0].Before; got != eloNewTeam {
+		t.Errorf("Gamma started on %v, want %v", got, eloNewTeam)
+	}
+}
@@ -0,0 +1,415 @@
+/*
+Builds league tables from fixture results, for tables the standings endpoint
+can't give, such as the table after an earlier round or one that includes
+matches still being played. Teams level on points are separated by the
+tiebreakers of the league, which TIEBREAKERS in the config can override.
+*/
+package cmd
+
+import (
+	"fmt"
+	"premcli/api"
+	"sort"
+	"strings"
+)
+
+// Names of the tiebreakers, in the form used by TIEBREAKERS
+const (
+	tiebreakPoints         = "points"
+	tiebreakGoalDifference = "goal_difference"
+	tiebreakGoalsFor       = "goals_for"
+	tiebreakWins           = "wins"
+	tiebreakAwayGoals      = "away_goals"
+	tiebreakHeadToHead     = "head_to_head"
+)
+
+// Works out a sort key for each team in a group of teams level on every
+// earlier tiebreaker. Higher keys rank higher.
+type tiebreaker func(group []*api.Standing, matches []api.Match) map[int][]int
+
+var tiebreakers = map[string]tiebreaker{
+	// Points come from the row rather than the record, so deductions count
+	tiebreakPoints: func(group []*api.Standing, matches []api.Match) map[int][]int {
+		return recordKeys(group, func(s *api.Standing) []int { return []int{s.Points} })
+	},
+	tiebreakGoalDifference: func(group []*api.Standing, matches []api.Match) map[int][]int {
+		return recordKeys(group, func(s *api.Standing) []int { return []int{s.All.GoalDifference()} })
+	},
+	tiebreakGoalsFor: func(group []*api.Standing, matches []api.Match) map[int][]int {
+		return recordKeys(group, func(s *api.Standing) []int { return []int{s.All.Goals.For} })
+	},
+	tiebreakWins: func(group []*api.Standing, matches []api.Match) map[int][]int {
+		return recordKeys(group, func(s *api.Standing) []int { return []int{s.All.Win} })
+	},
+	tiebreakAwayGoals: func(group []*api.Standing, matches []api.Match) map[int][]int {
+		return recordKeys(group, func(s *api.Standing) []int { return []int{s.Away.Goals.For} })
+	},
+	tiebreakHeadToHead: headToHeadKeys,
+}
+
+// Tiebreakers used by leagues without their own rules
+var defaultTiebreakers = []string{tiebreakPoints, tiebreakGoalDifference, tiebreakGoalsFor, tiebreakHeadToHead}
+
+// Tiebreakers of leagues whose rules differ from the default, by league ID
+var leagueTiebreakers = map[int][]string{
+	// La Liga and Serie A look at head-to-head results before goal difference
+	140: {tiebreakPoints, tiebreakHeadToHead, tiebreakGoalDifference, tiebreakGoalsFor},
+	135: {tiebreakPoints, tiebreakHeadToHead, tiebreakGoalDifference, tiebreakGoalsFor, tiebreakWins},
+	78:  {tiebreakPoints, tiebreakGoalDifference, tiebreakGoalsFor, tiebreakHeadToHead, tiebreakAwayGoals},
+	// The Champions League phase has no head-to-head
+	2: {tiebreakPoints, tiebreakGoalDifference, tiebreakGoalsFor, tiebreakAwayGoals, tiebreakWins},
+}
+
+// TIEBREAKERS from the config, e.g. "points,head_to_head,goal_difference"
+var tiebreakSetting string
+
+// Parses a comma separated list of tiebreakers
+func parseTiebreakers(setting string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(setting, ",") {
+		name = strings.TrimSpace(name)
+		if _, exists := tiebreakers[name]; !exists {
+			var known []string
+			for knownName := range tiebreakers {
+				known = append(known, knownName)
+			}
+			sort.Strings(known)
+			return nil, fmt.Errorf("Unknown tiebreaker %q, use %s", name, strings.Join(known, ", "))
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// Gets the tiebreakers of the followed league. TIEBREAKERS has already been
+// validated by GetConfig, and points always come first.
+func leagueTiebreakRules() []string {
+	if tiebreakSetting != "" {
+		names, err := parseTiebreakers(tiebreakSetting)
+		if err == nil {
+			if names[0] != tiebreakPoints {
+				names = append([]string{tiebreakPoints}, names...)
+			}
+			return names
+		}
+	}
+	if names, exists := leagueTiebreakers[league]; exists {
+		return names
+	}
+	return defaultTiebreakers
+}
+
+// Builds the sort keys of a group from each team's own record
+func recordKeys(group []*api.Standing, key func(*api.Standing) []int) map[int][]int {
+	keys := map[int][]int{}
+	for _, standing := range group {
+		keys[standing.Team.ID] = key(standing)
+	}
+	return keys
+}
+
+// Ranks a group by a mini-league of the matches between its teams: points,
+// then goal difference, then goals scored
+func headToHeadKeys(group []*api.Standing, matches []api.Match) map[int][]int {
+	inGroup := map[int]bool{}
+	for _, standing := range group {
+		inGroup[standing.Team.ID] = true
+	}
+
+	records := map[int]*api.Record{}
+	for id := range inGroup {
+		records[id] = &api.Record{}
+	}
+	for _, match := range matches {
+		home, away := match.Teams.Home.ID, match.Teams.Away.ID
+		if !inGroup[home] || !inGroup[away] || !countsInTable(match.Fixture.Status.Short) {
+			continue
+		}
+		addResult(records[home], match.Goals.Home, match.Goals.Away)
+		addResult(records[away], match.Goals.Away, match.Goals.Home)
+	}
+
+	keys := map[int][]int{}
+	for id, record := range records {
+		keys[id] = []int{record.Points(), record.GoalDifference(), record.Goals.For}
+	}
+	return keys
+}
+
+// Compares two sort keys, higher first
+func compareKeys(a, b []int) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] > b[i] {
+			return -1
+		}
+		if a[i] < b[i] {
+			return 1
+		}
+	}
+	return 0
+}
+
+// Orders the table by the tiebreakers in turn. Each one only reorders teams
+// still level after the ones before it, so head-to-head results are worked out
+// between exactly the teams that are tied. Teams level on everything are
+// ordered by name.
+func rankTable(table []api.Standing, matches []api.Match, rules []string) {
+	sort.SliceStable(table, func(i, j int) bool {
+		return table[i].Team.Name < table[j].Team.Name
+	})
+
+	rows := make([]*api.Standing, len(table))
+	for i := range table {
+		rows[i] = &table[i]
+	}
+
+	groups := [][]*api.Standing{rows}
+	for _, name := range rules {
+		var next [][]*api.Standing
+		for _, group := range groups {
+			if len(group) < 2 {
+				next = append(next, group)
+				continue
+			}
+
+			keys := tiebreakers[name](group, matches)
+			sort.SliceStable(group, func(i, j int) bool {
+				return compareKeys(keys[group[i].Team.ID], keys[group[j].Team.ID]) < 0
+			})
+
+			start := 0
+			for i := 1; i <= len(group); i++ {
+				if i == len(group) || compareKeys(keys[group[start].Team.ID], keys[group[i].Team.ID]) != 0 {
+					next = append(next, group[start:i])
+					start = i
+				}
+			}
+		}
+		groups = next
+	}
+
+	ranked := make([]api.Standing, 0, len(table))
+	for _, group := range groups {
+		for _, row := range group {
+			ranked = append(ranked, *row)
+		}
+	}
+	copy(table, ranked)
+
+	for i := range table {
+		table[i].Rank = i + 1
+	}
+}
+
+// Prefixes of the rounds played in the table. Qualifying rounds, play-offs
+// and knockout rounds are played outside of it.
+var tableRoundPrefixes = []string{"Regular Season", "League Stage", "League Phase", "Group"}
+
+// Checks if a round's results count towards the table, e.g. "Regular Season -
+// 10" or "League Stage - 3" but not "3rd Qualifying Round" or "Play-offs"
+func isTableRound(round string) bool {
+	for _, prefix := range tableRoundPrefixes {
+		if strings.HasPrefix(round, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Gets the rounds of the table among rounds, keeping their order
+func tableRounds(rounds []string) []string {
+	var table []string
+	for _, round := range rounds {
+		if isTableRound(round) {
+			table = append(table, round)
+		}
+	}
+	return table
+}
+
+// Gets the fixtures among matches played in rounds of the table
+func tableMatches(matches []api.Match) []api.Match {
+	var table []api.Match
+	for _, match := range matches {
+		if isTableRound(match.League.Round) {
+			table = append(table, match)
+		}
+	}
+	return table
+}
+
+// Gets the fixtures among matches between two teams of a group
+func groupMatches(group []api.Standing, matches []api.Match) []api.Match {
+	inGroup := map[int]bool{}
+	for _, standing := range group {
+		inGroup[standing.Team.ID] = true
+	}
+
+	var between []api.Match
+	for _, match := range matches {
+		if inGroup[match.Teams.Home.ID] && inGroup[match.Teams.Away.ID] {
+			between = append(between, match)
+		}
+	}
+	return between
+}
+
+// Checks if a fixture status means its result counts towards the table
+func countsInTable(status string) bool {
+	switch status {
+	case "FT", "AET", "PEN", "AWD", "WO":
+		return true
+	}
+	return false
+}
+
+// Treats matches being played as if they had finished with the current score
+func withLiveScores(matches []api.Match) []api.Match {
+	live := append([]api.Match(nil), matches...)
+	for i := range live {
+		if api.IsLive(live[i].Fixture.Status.Short) {
+			live[i].Fixture.Status.Short = "FT"
+		}
+	}
+	return live
+}
+
+// Adds a result to a team's record, from that team's point of view
+func addResult(record *api.Record, goalsFor, goalsAgainst int) {
+	record.Played++
+	record.Goals.For += goalsFor
+	record.Goals.Against += goalsAgainst
+
+	switch {
+	case goalsFor > goalsAgainst:
+		record.Win++
+	case goalsFor == goalsAgainst:
+		record.Draw++
+	default:
+		record.Lose++
+	}
+}
+
+// Adds a result to a row of the table, from that team's point of view. The
+// points of the result are added to the row's, so deductions carry over.
+func addRowResult(row *api.Standing, goalsFor, goalsAgainst int, home bool) {
+	points := row.All.Points()
+	addResult(&row.All, goalsFor, goalsAgainst)
+	if home {
+		addResult(&row.Home, goalsFor, goalsAgainst)
+	} else {
+		addResult(&row.Away, goalsFor, goalsAgainst)
+	}
+
+	row.Points += row.All.Points() - points
+	row.GoalsDiff = row.All.GoalDifference()
+	row.Form += resultLetter(goalsFor, goalsAgainst)
+	if len(row.Form) > 5 {
+		row.Form = row.Form[len(row.Form)-5:]
+	}
+}
+
+// Adds results to the rows of a table, such as a group of the API's standings,
+// and ranks it again. Everything the results don't change carries over,
+// including points deductions, and zones stay with their ranks. Results of
+// teams outside the table are skipped. played holds the results already in the
+// rows, for the head-to-head tiebreakers. Without any results the table keeps
+// its order.
+func applyResults(group []api.Standing, results, played []api.Match) []api.Standing {
+	table := append([]api.Standing(nil), group...)
+	rows := map[int]*api.Standing{}
+	zones := map[int]string{}
+	for i := range table {
+		rows[table[i].Team.ID] = &table[i]
+		zones[table[i].Rank] = table[i].Description
+	}
+
+	applied := false
+	for _, match := range results {
+		home, away := rows[match.Teams.Home.ID], rows[match.Teams.Away.ID]
+		if home == nil || away == nil || !countsInTable(match.Fixture.Status.Short) {
+			continue
+		}
+		addRowResult(home, match.Goals.Home, match.Goals.Away, true)
+		addRowResult(away, match.Goals.Away, match.Goals.Home, false)
+		applied = true
+	}
+	if !applied {
+		return table
+	}
+
+	all := append(append([]api.Match(nil), played...), results...)
+	rankTable(table, all, leagueTiebreakRules())
+	addZones(table, zones)
+	return table
+}
+
+// Gets the result letter used in the form guide
+func resultLetter(goalsFor, goalsAgainst int) string {
+	switch {
+	case goalsFor > goalsAgainst:
+		return "W"
+	case goalsFor == goalsAgainst:
+		return "D"
+	}
+	return "L"
+}
+
+// Builds the table from the finished fixtures among matches, ranked by the
+// followed league's tiebreakers. Every team in matches gets a row, even before
+// its first result. The form guide holds the last five results, the most
+// recent last.
+func buildTable(matches []api.Match) []api.Standing {
+	sorted := append([]api.Match(nil), matches...)
+	sortMatches(sorted)
+
+	table := tallyTable(sorted)
+	rankTable(table, sorted, leagueTiebreakRules())
+	return table
+}
+
+// Adds up the results of matches already in kickoff order into a row per
+// team, in the order teams first appear. The rows aren't ranked.
+func tallyTable(sorted []api.Match) []api.Standing {
+	rows := map[int]*api.Standing{}
+	var order []int
+	row := func(id int, name string) *api.Standing {
+		if standing, exists := rows[id]; exists {
+			return standing
+		}
+		standing := &api.Standing{}
+		standing.Team.ID = id
+		standing.Team.Name = name
+		rows[id] = standing
+		order = append(order, id)
+		return standing
+	}
+
+	for _, match := range sorted {
+		home := row(match.Teams.Home.ID, match.Teams.Home.Name)
+		away := row(match.Teams.Away.ID, match.Teams.Away.Name)
+		if !countsInTable(match.Fixture.Status.Short) {
+			continue
+		}
+
+		homeGoals, awayGoals := match.Goals.Home, match.Goals.Away
+		addResult(&home.All, homeGoals, awayGoals)
+		addResult(&home.Home, homeGoals, awayGoals)
+		addResult(&away.All, awayGoals, homeGoals)
+		addResult(&away.Away, awayGoals, homeGoals)
+		home.Form += resultLetter(homeGoals, awayGoals)
+		away.Form += resultLetter(awayGoals, homeGoals)
+	}
+
+	table := make([]api.Standing, 0, len(order))
+	for _, id := range order {
+		standing := *rows[id]
+		standing.Points = standing.All.Points()
+		standing.GoalsDiff = standing.All.GoalDifference()
+		if len(standing.Form) > 5 {
+			standing.Form = standing.Form[len(standing.Form)-5:]
+		}
+		table = append(table, standing)
+	}
+	return table
+}
@@ -0,0 +1,178 @@
+/*
+Tests that worked out tables separate teams by the league's tiebreakers and that
+results added to the API's table keep its deductions and zones.
+*/
+package cmd
+
+import (
+	"premcli/api"
+	"testing"
+)
+
+// Creates a fixture between two teams with the given status. Teams are named
+// after their IDs, see testTeamNames.
+func testFixture(home, away int, status string, homeGoals, awayGoals int) api.Match {
+	var match api.Match
+	match.Teams.Home.ID, match.Teams.Home.Name = home, testTeamNames[home]
+	match.Teams.Away.ID, match.Teams.Away.Name = away, testTeamNames[away]
+	match.Goals.Home, match.Goals.Away = homeGoals, awayGoals
+	match.Fixture.Status.Short = status
+	match.League.Round = "Regular Season - 1"
+	return match
+}
+
+var testTeamNames = map[int]string{1: "Alpha", 2: "Beta", 3: "Gamma", 4: "Delta"}
+
+// Sets the followed league and TIEBREAKERS for a test
+func useTiebreakers(t *testing.T, leagueID int, setting string) {
+	oldLeague, oldSetting := league, tiebreakSetting
+	league, tiebreakSetting = leagueID, setting
+	t.Cleanup(func() {
+		league, tiebreakSetting = oldLeague, oldSetting
+	})
+}
+
+// Gets the team IDs of a table in order
+func tableOrder(table []api.Standing) []int {
+	var ids []int
+	for _, standing := range table {
+		ids = append(ids, standing.Team.ID)
+	}
+	return ids
+}
+
+func equalOrder(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Alpha and Beta end level on 3 points. Beta has the better goal difference
+// but Alpha won the match between them.
+var headToHeadFixtures = []api.Match{
+	testFixture(1, 2, "FT", 1, 0),
+	testFixture(2, 3, "FT", 5, 0),
+	testFixture(4, 1, "FT", 1, 0),
+	testFixture(3, 4, "FT", 0, 0),
+}
+
+func TestRankTableTiebreakers(t *testing.T) {
+	tests := []struct {
+		name    string
+		league  int
+		setting string
+		want    []int
+	}{
+		// Premier League goes by goal difference first
+		{"goal difference", premierLeague, "", []int{4, 2, 1, 3}},
+		// La Liga looks at head-to-head results first
+		{"head to head", 140, "", []int{4, 1, 2, 3}},
+		// TIEBREAKERS overrides the league's rules and points stay first
+		{"config", premierLeague, "head_to_head,goal_difference", []int{4, 1, 2, 3}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useTiebreakers(t, test.league, test.setting)
+
+			table := buildTable(headToHeadFixtures)
+			if got := tableOrder(table); !equalOrder(got, test.want) {
+				t.Errorf("got order %v, want %v", got, test.want)
+			}
+			for i, standing := range table {
+				if standing.Rank != i+1 {
+					t.Errorf("%s has rank %d at position %d", standing.Team.Name, standing.Rank, i+1)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildTableSkipsUnfinished(t *testing.T) {
+	useTiebreakers(t, premierLeague, "")
+
+	table := buildTable([]api.Match{
+		testFixture(1, 2, "FT", 2, 1),
+		testFixture(2, 1, "NS", 0, 0),
+		testFixture(3, 4, "CANC", 0, 0),
+	})
+
+	if len(table) != 4 {
+		t.Fatalf("got %d rows, want a row for every team", len(table))
+	}
+	for _, standing := range table {
+		if standing.Team.ID == 1 && (standing.Points != 3 || standing.All.Played != 1) {
+			t.Errorf("Alpha has %d points from %d matches, want 3 from 1", standing.Points, standing.All.Played)
+		}
+		if standing.Team.ID == 3 && standing.All.Played != 0 {
+			t.Errorf("Gamma has played %d matches, want 0", standing.All.Played)
+		}
+	}
+}
+
+func TestApplyResultsKeepsDeductions(t *testing.T) {
+	useTiebreakers(t, premierLeague, "")
+
+	// Beta has had 6 points deducted from a record worth 11
+	var alpha, beta api.Standing
+	alpha.Rank, alpha.Team.ID, alpha.Team.Name, alpha.Points = 1, 1, "Alpha", 10
+	alpha.All = api.Record{Played: 4, Win: 3, Draw: 1}
+	alpha.Description = "Promotion - Champions League"
+	beta.Rank, beta.Team.ID, beta.Team.Name, beta.Points = 2, 2, "Beta", 5
+	beta.All = api.Record{Played: 5, Win: 3, Draw: 2}
+
+	table := applyResults([]api.Standing{alpha, beta}, []api.Match{testFixture(2, 1, "FT", 2, 0)}, nil)
+
+	if got, want := tableOrder(table), []int{1, 2}; !equalOrder(got, want) {
+		t.Fatalf("got order %v, want %v", got, want)
+	}
+	if table[1].Points != 8 || table[1].All.Played != 6 || table[1].Home.Win != 1 {
+		t.Errorf("Beta has %d points from %d matches, want 8 from 6 with a home win", table[1].Points, table[1].All.Played)
+	}
+	if table[0].Description != "Promotion - Champions League" || table[1].Description != "" {
+		t.Errorf("zones moved with their teams instead of staying with their ranks")
+	}
+}
+
+func TestApplyResultsWithoutResults(t *testing.T) {
+	useTiebreakers(t, premierLeague, "")
+
+	// The API's order is kept even where the tiebreakers would swap teams
+	var beta, alpha api.Standing
+	beta.Rank, beta.Team.ID, beta.Team.Name = 1, 2, "Beta"
+	alpha.Rank, alpha.Team.ID, alpha.Team.Name = 2, 1, "Alpha"
+
+	table := applyResults([]api.Standing{beta, alpha}, []api.Match{testFixture(3, 4, "FT", 1, 0)}, nil)
+	if got, want := tableOrder(table), []int{2, 1}; !equalOrder(got, want) {
+		t.Errorf("got order %v, want %v", got, want)
+	}
+}
+
+func TestIsTableRound(t *testing.T) {
+	tests := []struct {
+		round string
+		want  bool
+	}{
+		{"Regular Season - 12", true},
+		{"League Stage - 3", true},
+		{"Group Stage - 2", true},
+		{"Group A - 1", true},
+		{"3rd Qualifying Round", false},
+		{"Play-offs", false},
+		{"Knockout Round Play-offs", false},
+		{"Round of 16", false},
+		{"Final", false},
+	}
+
+	for _, test := range tests {
+		if got := isTableRound(test.round); got != test.want {
+			t.Errorf("isTableRound(%q) = %v, want %v", test.round, got, test.want)
+		}
+	}
+}
@@ -1,15 +1,13 @@
 /*
-Displays the fixtures for the current round. If specified can display the previous
-and next rounds fixtures.
+Displays the fixtures for the current round. If specified can display any other
+round, such as the previous and next rounds fixtures.
 */
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
+	"os"
+	"premcli/api"
 	"sort"
 	"strconv"
 	"strings"
@@ -19,307 +17,369 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var (
-	roundValue string
-)
-
-type ApiResponseFixture struct {
-	Response []Match `json:"response"`
-}
-
-type Match struct {
-	Fixture struct {
-		ID     int
-		Date   string
-		Status struct {
-			Short   string
-			Elapsed int
+// Finds a round by its full name or its number, e.g. "Regular Season - 12" or "12"
+func findRound(rounds []string, round string) (int, error) {
+	for i, name := range rounds {
+		if strings.EqualFold(name, round) {
+			return i, nil
 		}
 	}
-	Teams struct {
-		Home struct {
-			Name string
+
+	if _, err := strconv.Atoi(round); err == nil {
+		var matches []int
+		for i, name := range rounds {
+			parts := strings.Split(name, " ")
+			if parts[len(parts)-1] == round {
+				matches = append(matches, i)
+			}
 		}
-		Away struct {
-			Name string
+
+		if len(matches) == 1 {
+			return matches[0], nil
+		}
+		if len(matches) > 1 {
+			var names []string
+			for _, i := range matches {
+				names = append(names, rounds[i])
+			}
+			return 0, fmt.Errorf("Round %s is ambiguous, use one of: %s", round, strings.Join(names, ", "))
 		}
 	}
-	Goals struct {
-		Home int
-		Away int
-	}
-}
 
-type CurrentRound struct {
-	Response []string `json:"response"`
+	return 0, fmt.Errorf("There is no round %q this season. Rounds run from %q to %q", round, rounds[0], rounds[len(rounds)-1])
 }
 
-// Helper function to get the current round for the API URL
-func getCurrentRound(previous bool, next bool) error {
-	url := buildRoundURL()
+// Resolves the round to display. The round is a name, a number or "current",
+// and offset moves that many rounds forward or back from it. Rounds are
+// validated against the season's real round list, which also includes cup
+// and play-off rounds.
+func resolveRound(client *api.Client, round string, offset int) (string, error) {
+	season := getSeason(client)
 
-	req, err := http.NewRequest("GET", url, nil)
+	rounds, err := client.Rounds(league, season)
 	if err != nil {
-		return fmt.Errorf("Error creating request: %v", err)
+		return "", err
 	}
-
-	req.Header.Add("X-RapidAPI-Key", apiKey)
-	req.Header.Add("X-RapidAPI-Host", "api-football-v1.p.rapidapi.com")
-
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("Error executing request: %v", err)
+	if len(rounds) == 0 {
+		return "", fmt.Errorf("No round information found in the API response")
 	}
 
-	defer res.Body.Close()
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		return fmt.Errorf("Error reasing response: %v", err)
+	if round == "" || strings.EqualFold(round, "current") {
+		round, err = client.CurrentRound(league, season)
+		if err != nil {
+			return "", err
+		}
 	}
 
-	var responseData CurrentRound
-	err = json.Unmarshal(body, &responseData)
+	index, err := findRound(rounds, round)
 	if err != nil {
-		return fmt.Errorf("Error parsing JSON response: %v", err)
+		return "", err
 	}
 
-	if len(responseData.Response) > 0 {
-		roundValue = responseData.Response[0]
-	} else {
-		return fmt.Errorf("No round information found in the API response")
+	target := index + offset
+	if target < 0 || target >= len(rounds) {
+		return "", fmt.Errorf("Offset %+d from %s is out of range, the season runs from %s to %s", offset, rounds[index], rounds[0], rounds[len(rounds)-1])
 	}
 
-	// Edit currentRound to if previous or next flag called
-	// This is kind of stupid but it's faster than another API call
-	if previous || next {
-		parts := strings.Split(roundValue, " ")
-		if len(parts) > 0 {
-			lastPart := parts[len(parts)-1]
-			roundNum, err := strconv.Atoi(lastPart)
-
-			if err != nil {
-				return fmt.Errorf("Error parsing round number: %v", err)
-			}
-
-			if roundNum > 1 {
-				if previous {
-					roundNum--
-
-				} else {
-					roundNum++
-				}
+	return rounds[target], nil
+}
 
-				roundValue = strings.Join(parts[:len(parts)-1], " ") + fmt.Sprintf(" %d", roundNum)
-			} else {
-				return fmt.Errorf("There is no previous round availible")
-			}
-		} else {
-			return fmt.Errorf("Unexpected round format: %v", roundValue)
-		}
+// Formats the Date and Time to something that is human readable
+func FormatTime(isoTime string) (string, error) {
+	parsedTime, err := time.Parse(time.RFC3339, isoTime)
+	if err != nil {
+		return "", fmt.Errorf("Error parsing time: %v", err)
 	}
 
-	return nil
+	return parsedTime.Format("02 Jan 2006, 03:04 PM"), nil
 }
 
-// Build the fixtures URL for the API
-func buildFixturesURL() string {
-	baseURL := "https://api-football-v1.p.rapidapi.com/v3/fixtures?league=39"
-
-	season := "&season=" + getSeasonYear()
-
-	round := "&round=" + url.QueryEscape(roundValue)
-	tz := "&timezone=" + url.QueryEscape(timezone)
-
-	return baseURL + season + round + tz
+// Sorts the fixtures by their kickoff
+func sortMatches(matches []api.Match) {
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Kickoff().Before(matches[j].Kickoff())
+	})
 }
 
-// Build the round URL for the API
-func buildRoundURL() string {
-	baseURL := "https://api-football-v1.p.rapidapi.com/v3/fixtures/rounds?league=39&current=true"
-
-	season := "&season=" + getSeasonYear()
-
-	return baseURL + season
+// Checks if the team plays in the fixture
+func playsIn(match api.Match, team string) bool {
+	return isFavTeam(match.Teams.Home.ID, team) || isFavTeam(match.Teams.Away.ID, team)
 }
 
-// Get the Fixtures and parse the JSON
-func getFixtures() ([]Match, error) {
-	url := buildFixturesURL()
-
-	req, err := http.NewRequest("GET", url, nil)
+// Formats a fixture for display
+func formatFixture(match api.Match) (string, error) {
+	homeTeam := match.Teams.Home.Name
+	homeScore := match.Goals.Home
+	awayTeam := match.Teams.Away.Name
+	awayScore := match.Goals.Away
+	date := match.Fixture.Date
+	fixtureID := match.Fixture.ID
+	timeElapsed := match.Fixture.Status.Elapsed
+	matchStatus := match.Fixture.Status.Short
+
+	// Reformat time so its readable
+	userFriendlyTime, err := FormatTime(date)
 	if err != nil {
-		return nil, fmt.Errorf("Error creating request: %v", err)
+		return "", err
 	}
 
-	req.Header.Add("X-RapidAPI-Key", apiKey)
-	req.Header.Add("X-RapidAPI-Host", "api-football-v1.p.rapidapi.com")
+	// Score Padding
+	const nameScoreWidth = 26
+	const vsWidth = 25
 
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("Error executing request: %v", err)
-	}
+	homePadding := nameScoreWidth - len("[H]") - len(homeTeam) - len(fmt.Sprint(homeScore))
+	awayPadding := nameScoreWidth - len("[A]") - len(awayTeam) - len(fmt.Sprint(awayScore))
+	vsPadding := vsWidth - len("[H]") - len(homeTeam) - len(fmt.Sprint(vsWidth))
 
-	defer res.Body.Close()
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, fmt.Errorf("Error reading response: %v", err)
-	}
-
-	var responseData ApiResponseFixture
-	err = json.Unmarshal(body, &responseData)
-	if err != nil {
-		return nil, fmt.Errorf("Error parsing JSON response: %v", err)
+	matchDisplay := ""
+	if matchStatus == "NS" {
+		// Match hasn't started
+		matchDisplay = fmt.Sprintf("Date: %s\n[H] %s%*s%s\n[A] %s%*s\nStatus: Game Hasn't Started.\nFixture ID: %d\n", userFriendlyTime, homeTeam, vsPadding, "", "vs.", awayTeam, awayPadding, "", fixtureID)
+	} else {
+		if matchStatus == "FT" {
+			// Match has finished
+			matchDisplay = fmt.Sprintf("Date: %s\n[H] %s%*s%d\n[A] %s%*s%d\nStatus: Game Has Finished.\nFixture ID: %d\n", userFriendlyTime, homeTeam, homePadding, "", homeScore, awayTeam, awayPadding, "", awayScore, fixtureID)
+		} else {
+			// Match in progress
+			matchDisplay = fmt.Sprintf("Date: %s\n[H] %s%*s%d\n[A] %s%*s%d\nTime Elapsed: %d\nFixture ID: %d\n", userFriendlyTime, homeTeam, homePadding, "", homeScore, awayTeam, awayPadding, "", awayScore, timeElapsed, fixtureID)
+		}
 	}
 
-	return responseData.Response, nil
+	return matchDisplay, nil
 }
 
-// Formats the Date and Time to something that is human readable
-func FormatTime(isoTime string) (string, error) {
-	parsedTime, err := time.Parse(time.RFC3339, isoTime)
-	if err != nil {
-		return "", fmt.Errorf("Error parsing time: %v", err)
+// Prints a round title followed by its fixtures sorted by date, highlighting the favourite team.
+// With ratings, fixtures yet to kick off show each result's chance.
+func printRound(round string, matches []api.Match, ratings *eloRatings) error {
+	// Highlight Round title
+	color.Set(color.Underline)
+	fmt.Println(round)
+	color.Unset()
+
+	// Sort and colour fixtures
+	// Print to terminal
+	sortMatches(matches)
+	for _, match := range matches {
+		matchDisplay, err := formatFixture(match)
+		if err != nil {
+			return err
+		}
+		if ratings != nil && match.Fixture.Status.Short == "NS" {
+			matchDisplay += fmt.Sprintf("Chances: %s\n", formatEloChances(ratings, match))
+		}
+
+		if playsIn(match, favTeam) {
+			color.Set(color.FgMagenta)
+			fmt.Println(matchDisplay)
+			color.Unset()
+			continue
+		}
+		fmt.Println(matchDisplay)
 	}
 
-	return parsedTime.Format("02 Jan 2006, 03:04 PM"), nil
+	return nil
 }
 
-// Helper function to get the date from a string. Used for sorting fixtures by date.
-func getDateFromMatchDisplay(matchDisplay string) string {
-	dateString := strings.Split(matchDisplay, "Date: ")[1][:21]
-	return dateString
+// Keeps only the fixtures the team plays in
+func filterTeam(matches []api.Match, team string) []api.Match {
+	var filtered []api.Match
+	for _, match := range matches {
+		if playsIn(match, team) {
+			filtered = append(filtered, match)
+		}
+	}
+	return filtered
 }
 
-// Sorts the fixtures by the date
-func sortFixtures(fixturesArr []string) []string {
-	layout := "02 Jan 2006, 03:04 PM"
+type roundFixtures struct {
+	Round   string
+	Matches []api.Match
+}
 
-	sort.Slice(fixturesArr, func(i, j int) bool {
-		iDateString := getDateFromMatchDisplay(fixturesArr[i])
-		jDateString := getDateFromMatchDisplay(fixturesArr[j])
+// Groups fixtures by their league.round, ordering the rounds by their first kickoff
+func groupByRound(matches []api.Match) []roundFixtures {
+	var rounds []roundFixtures
+	index := map[string]int{}
+	firstKickoff := map[string]time.Time{}
+
+	for _, match := range matches {
+		round := match.League.Round
+		i, exists := index[round]
+		if !exists {
+			i = len(rounds)
+			index[round] = i
+			rounds = append(rounds, roundFixtures{Round: round})
+		}
+		rounds[i].Matches = append(rounds[i].Matches, match)
 
-		iDate, _ := time.Parse(layout, iDateString)
-		jDate, _ := time.Parse(layout, jDateString)
+		kickoff := match.Kickoff()
+		if firstKickoff[round].IsZero() || kickoff.Before(firstKickoff[round]) {
+			firstKickoff[round] = kickoff
+		}
+	}
 
-		return iDate.Before(jDate)
+	sort.SliceStable(rounds, func(i, j int) bool {
+		return firstKickoff[rounds[i].Round].Before(firstKickoff[rounds[j].Round])
 	})
 
-	return fixturesArr
+	return rounds
 }
 
-// Helper function for getting the Team names from a string. Used to highlight the favourite team fixture
-func extractTeams(match string) (string, string) {
-	if strings.Contains(match, " vs. ") {
-		// Handle the "[H] Sheffield Utd vs. Wolves [A]" format
-		parts := strings.Split(match, " vs. ")
-		homeTeam := strings.Trim(parts[0][4:], " ")
-		awayTeam := strings.Trim(parts[1][:len(parts[1])-4], " ")
-		return homeTeam, awayTeam
-	} else {
-		// Handle the "[H] Sheffield Utd 2 - 3 Wolves [A]" format
-		parts := strings.Split(match, " - ")
-		home := strings.Split(parts[0], " ")
-		away := strings.Split(parts[1], " ")
-
-		homeTeam := strings.Trim(home[len(home)-2][4:], " ") // Taking the second-last word after [H] prefix
-		awayTeam := strings.Trim(away[1], " ")               // Taking the first word before [A] suffix
+// Writes the fixtures as a calendar or in the chosen machine-readable format
+func writeMatches(client *api.Client, matches []api.Match, team string, ics bool) error {
+	sortMatches(matches)
+	if !ics {
+		return writeFixtures(matches)
+	}
 
-		return homeTeam, awayTeam
+	name := leagueName(client) + " fixtures"
+	if t, err := resolveTeam(team); err == nil {
+		name = t.Name + " fixtures"
 	}
+	return writeCalendar(os.Stdout, name, matches)
 }
 
 var fixturesCmd = &cobra.Command{
 	Use:   "fixtures",
 	Short: "Prints fixtures for current round",
-	Long:  "Prints fixtures for current round and highlights in bold the fixture of your favourite team.",
+	Long: `Prints fixtures for current round and highlights in bold the fixture of your favourite team.
+
+Use --all to print the fixtures of the whole season grouped by round.`,
+	Annotations: map[string]string{machineOutputAnnotation: ""},
 	Run: func(cmd *cobra.Command, args []string) {
 		// Get flags if called
 		previousRound, _ := cmd.Flags().GetBool("previous")
 		nextRound, _ := cmd.Flags().GetBool("next")
+		round, _ := cmd.Flags().GetString("round")
+		offset, _ := cmd.Flags().GetInt("offset")
+		allRounds, _ := cmd.Flags().GetBool("all")
+		team, _ := cmd.Flags().GetString("team")
+		ics, _ := cmd.Flags().GetBool("ics")
+		elo, _ := cmd.Flags().GetBool("elo")
 
 		// Gets the config
 		err := GetConfig()
 		if err != nil {
-			fmt.Println("Error loading config:", err)
-			return
+			exitWithError("Error loading config:", err)
 		}
 
-		// Gets the currentRound
-		err = getCurrentRound(previousRound, nextRound)
-		if err != nil {
-			fmt.Println("Error getting current round:", err)
-			return
+		if ics && machineOutput() {
+			exitWithError("Invalid flags:", fmt.Errorf("--ics can't be combined with --output"))
+		}
+		if elo && (ics || machineOutput()) {
+			exitWithError("Invalid flags:", fmt.Errorf("--elo can't be combined with --ics or --output"))
 		}
 
-		// Gets fixtures
-		matches, err := getFixtures()
+		if allRounds && (round != "" || offset != 0 || previousRound || nextRound) {
+			exitWithError("Invalid flags:", fmt.Errorf("--all can't be combined with a round"))
+		}
+
+		// --previous and --next are shorthands for an offset of one round
+		if previousRound && nextRound {
+			exitWithError("Invalid flags:", fmt.Errorf("--previous and --next can't be used together"))
+		}
+		if previousRound {
+			offset--
+		}
+		if nextRound {
+			offset++
+		}
+
+		// The whole season is a single request, which ratings also need. A
+		// round needs the season's rounds, the current round and its fixtures.
+		planned := 1
+		if !allRounds {
+			planned = 3
+			if elo {
+				planned++
+			}
+		}
+		planned += extraRequests(true, team != "" || favTeam != "")
+		err = checkQuota(planned)
 		if err != nil {
-			fmt.Println("Error fetching and parsing:", err)
-			return
+			exitWithError("Not enough API quota:", err)
 		}
 
-		// Highlight Round title
-		color.Set(color.Underline)
-		fmt.Println(roundValue)
-		color.Unset()
-
-		var fixturesArr []string
-
-		// Loop through each fixture and store in output array
-		for _, match := range matches {
-			homeTeam := match.Teams.Home.Name
-			homeScore := match.Goals.Home
-			awayTeam := match.Teams.Away.Name
-			awayScore := match.Goals.Away
-			date := match.Fixture.Date
-			fixtureID := match.Fixture.ID
-			timeElapsed := match.Fixture.Status.Elapsed
-			matchStatus := match.Fixture.Status.Short
-
-			// Reformat time so its readable
-			userFriendlyTime, err := FormatTime(date)
+		client := newClient()
+
+		// Teams are needed to filter by team, otherwise only to highlight the favourite
+		if team != "" {
+			err = loadTeams(client)
 			if err != nil {
-				fmt.Println(err)
+				exitWithError("Error loading teams:", err)
+			}
+			_, err = resolveTeam(team)
+			if err != nil {
+				exitWithError("Invalid team:", err)
+			}
+		} else {
+			loadFavTeam(client)
+		}
+
+		// Ratings need the whole season's fixtures
+		var ratings *eloRatings
+		if elo {
+			ratings, _, err = loadEloRatings(client)
+			if err != nil {
+				exitWithError("Error working out ratings:", err)
+			}
+		}
+
+		if allRounds {
+			matches, err := client.Fixtures(league, getSeason(client), "", timezone)
+			if err != nil {
+				exitWithError("Error fetching and parsing:", err)
+			}
+
+			if team != "" {
+				matches = filterTeam(matches, team)
+			}
+
+			if machineOutput() || ics {
+				err = writeMatches(client, matches, team, ics)
+				if err != nil {
+					exitWithError("Error writing output:", err)
+				}
 				return
 			}
 
-			// Score Padding
-			const nameScoreWidth = 26
-			const vsWidth = 25
-
-			homePadding := nameScoreWidth - len("[H]") - len(homeTeam) - len(fmt.Sprint(homeScore))
-			awayPadding := nameScoreWidth - len("[A]") - len(awayTeam) - len(fmt.Sprint(awayScore))
-			vsPadding := vsWidth - len("[H]") - len(homeTeam) - len(fmt.Sprint(vsWidth))
-
-			matchDisplay := ""
-			if matchStatus == "NS" {
-				// Match hasn't started
-				matchDisplay = fmt.Sprintf("Date: %s\n[H] %s%*s%s\n[A] %s%*s\nStatus: Game Hasn't Started.\nFixture ID: %d\n", userFriendlyTime, homeTeam, vsPadding, "", "vs.", awayTeam, awayPadding, "", fixtureID)
-			} else {
-				if matchStatus == "FT" {
-					// Match has finished
-					matchDisplay = fmt.Sprintf("Date: %s\n[H] %s%*s%d\n[A] %s%*s%d\nStatus: Game Has Finished.\nFixture ID: %d\n", userFriendlyTime, homeTeam, homePadding, "", homeScore, awayTeam, awayPadding, "", awayScore, fixtureID)
-				} else {
-					// Match in progress
-					matchDisplay = fmt.Sprintf("Date: %s\n[H] %s%*s%d\n[A] %s%*s%d\nTime Elapsed: %d\nFixture ID: %d\n", userFriendlyTime, homeTeam, homePadding, "", homeScore, awayTeam, awayPadding, "", awayScore, timeElapsed, fixtureID)
+			for _, round := range groupByRound(matches) {
+				err = printRound(round.Round, round.Matches, ratings)
+				if err != nil {
+					exitWithError("Error formatting fixtures:", err)
 				}
 			}
+			return
+		}
+
+		// Gets the round
+		roundValue, err := resolveRound(client, round, offset)
+		if err != nil {
+			exitWithError("Error getting round:", err)
+		}
+
+		// Gets fixtures
+		matches, err := client.Fixtures(league, getSeason(client), roundValue, timezone)
+		if err != nil {
+			exitWithError("Error fetching and parsing:", err)
+		}
 
-			fixturesArr = append(fixturesArr, matchDisplay)
+		if team != "" {
+			matches = filterTeam(matches, team)
 		}
-		// Sort and colour fixtures
-		// Print to terminal
-		sortFixtures(fixturesArr)
-		for _, fixture := range fixturesArr {
-			if isFavTeam(fixture, favTeam) {
-				color.Set(color.FgMagenta)
-				fmt.Println(fixture)
-				color.Unset()
-				continue
+
+		if machineOutput() || ics {
+			err = writeMatches(client, matches, team, ics)
+			if err != nil {
+				exitWithError("Error writing output:", err)
 			}
-			fmt.Println(fixture)
+			return
 		}
 
+		err = printRound(roundValue, matches, ratings)
+		if err != nil {
+			exitWithError("Error formatting fixtures:", err)
+		}
 	},
 }
 
@@ -328,4 +388,10 @@ func init() {
 
 	fixturesCmd.PersistentFlags().BoolP("previous", "p", false, "Get fixtures for the previous round")
 	fixturesCmd.PersistentFlags().BoolP("next", "n", false, "Get fixtures for the next round")
+	fixturesCmd.PersistentFlags().StringP("round", "r", "", "Get fixtures for a round by number or name, or \"current\"")
+	fixturesCmd.PersistentFlags().Int("offset", 0, "Move this many rounds forward or back, e.g. -3")
+	fixturesCmd.PersistentFlags().BoolP("all", "a", false, "Get fixtures for the whole season")
+	fixturesCmd.PersistentFlags().StringP("team", "t", "", "Only show fixtures of a team by code or name, e.g. WOL or Spurs")
+	fixturesCmd.PersistentFlags().Bool("ics", false, "Write the fixtures as an iCalendar file")
+	fixturesCmd.PersistentFlags().Bool("elo", false, "Show the win, draw and loss chances of fixtures yet to kick off from Elo ratings")
 }
@@ -0,0 +1,53 @@
+/*
+Tests that rounds are found by their full name or their number.
+*/
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindRound(t *testing.T) {
+	rounds := []string{"Regular Season - 1", "Regular Season - 2", "Regular Season - 3"}
+
+	tests := []struct {
+		round string
+		want  int
+	}{
+		{"Regular Season - 2", 1},
+		{"regular season - 3", 2},
+		{"1", 0},
+		{"3", 2},
+	}
+
+	for _, test := range tests {
+		got, err := findRound(rounds, test.round)
+		if err != nil {
+			t.Errorf("findRound(%q): %v", test.round, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("findRound(%q) = %d, want %d", test.round, got, test.want)
+		}
+	}
+}
+
+func TestFindRoundErrors(t *testing.T) {
+	tests := []struct {
+		rounds []string
+		round  string
+		want   string
+	}{
+		{[]string{"Regular Season - 1", "Regular Season - 2"}, "5", "There is no round"},
+		{[]string{"Regular Season - 1", "Regular Season - 2"}, "Final", "There is no round"},
+		{[]string{"Group Stage - 1", "Round of 16 - 1"}, "1", "ambiguous"},
+	}
+
+	for _, test := range tests {
+		_, err := findRound(test.rounds, test.round)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("findRound(%q) = %v, want an error containing %q", test.round, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+/*
+Round-by-round tables of a season. Tables come from snapshots in the local store
+where one was recorded and are rebuilt from fixture results otherwise. Only
+rounds of the table are counted, so qualifying rounds and play-offs are left
+out.
+*/
+package cmd
+
+import (
+	"fmt"
+	"premcli/api"
+	"time"
+)
+
+type seasonHistory struct {
+	// Rounds of the table, in order
+	rounds  []string
+	matches []api.Match
+	// Round number of each round name, counting from 1
+	roundNumbers map[string]int
+	// Groups of the current table. Rebuilt tables have a table per group with
+	// the zones of its ranks.
+	groups [][]api.Standing
+	store  *seasonStore
+}
+
+// Loads the rounds and fixtures of the season's table and the stored snapshots
+func loadHistory(client *api.Client) (*seasonHistory, error) {
+	season := getSeason(client)
+
+	allRounds, err := client.Rounds(league, season)
+	if err != nil {
+		return nil, err
+	}
+	rounds := tableRounds(allRounds)
+	if len(rounds) == 0 {
+		return nil, fmt.Errorf("No round information found in the API response")
+	}
+
+	matches, err := client.Fixtures(league, season, "", timezone)
+	if err != nil {
+		return nil, err
+	}
+	matches = tableMatches(matches)
+
+	var groups [][]api.Standing
+	if standings, err := client.Standings(league, season); err == nil {
+		for _, leagueData := range standings {
+			groups = append(groups, leagueData.League.Standings...)
+		}
+	}
+
+	store, err := loadStore(league, season)
+	if err != nil {
+		return nil, err
+	}
+	store.addMatches(matches)
+
+	history := &seasonHistory{
+		rounds:       rounds,
+		matches:      matches,
+		roundNumbers: map[string]int{},
+		groups:       groups,
+		store:        store,
+	}
+	for i, round := range rounds {
+		history.roundNumbers[round] = i + 1
+	}
+
+	return history, nil
+}
+
+// Gets the number of the last round with a result in it
+func (h *seasonHistory) lastPlayedRound() int {
+	last := 0
+	for _, match := range h.matches {
+		if countsInTable(match.Fixture.Status.Short) && h.roundNumbers[match.League.Round] > last {
+			last = h.roundNumbers[match.League.Round]
+		}
+	}
+	return last
+}
+
+// Gets the table after a round, the groups one after another. Rebuilt tables
+// are stored once every fixture up to that round has been played, so they are
+// only worked out once.
+func (h *seasonHistory) table(round int) []api.Standing {
+	name := h.rounds[round-1]
+	if snap, ok := h.store.snapshot(name); ok {
+		return snap.Standings
+	}
+
+	var played []api.Match
+	complete := true
+	for _, match := range h.matches {
+		number, exists := h.roundNumbers[match.League.Round]
+		if !exists || number > round {
+			continue
+		}
+		played = append(played, match)
+		if !countsInTable(match.Fixture.Status.Short) {
+			complete = false
+		}
+	}
+
+	table := h.buildTables(played)
+	if complete {
+		h.store.putSnapshot(snapshot{Round: name, RecordedAt: time.Now(), Source: sourceFixtures, Standings: table})
+	}
+	return table
+}
+
+// Builds a table per group of the current table from results, each with the
+// zones of the group's ranks. Without the current table every team goes in one
+// table.
+func (h *seasonHistory) buildTables(matches []api.Match) []api.Standing {
+	if len(h.groups) == 0 {
+		return buildTable(matches)
+	}
+
+	var tables []api.Standing
+	for _, group := range h.groups {
+		zones := map[int]string{}
+		for _, standing := range group {
+			zones[standing.Rank] = standing.Description
+		}
+
+		table := buildTable(groupMatches(group, matches))
+		addZones(table, zones)
+		for i := range table {
+			table[i].Group = group[0].Group
+		}
+		tables = append(tables, table...)
+	}
+	return tables
+}
+
+// Saves the fixtures and snapshots worked out while loading the history
+func (h *seasonHistory) save() error {
+	return h.store.save()
+}
+
+// Gets the table after a round, given by number or name, shaped like the
+// standings endpoint's response so it prints the same way
+func (h *seasonHistory) standingsAfter(round, name string) ([]api.Standings, error) {
+	index, err := findRound(h.rounds, round)
+	if err != nil {
+		return nil, err
+	}
+	if index+1 > h.lastPlayedRound() {
+		return nil, fmt.Errorf("%s hasn't been played yet", h.rounds[index])
+	}
+
+	// The groups of a table follow each other
+	var tables [][]api.Standing
+	for _, standing := range h.table(index + 1) {
+		if len(tables) == 0 || tables[len(tables)-1][0].Group != standing.Group {
+			tables = append(tables, nil)
+		}
+		tables[len(tables)-1] = append(tables[len(tables)-1], standing)
+	}
+	for _, table := range tables {
+		title := name
+		if len(tables) > 1 {
+			title = table[0].Group
+		}
+		for i := range table {
+			table[i].Group = fmt.Sprintf("%s after %s", title, h.rounds[index])
+		}
+	}
+
+	var leagueData api.Standings
+	leagueData.League.ID = league
+	leagueData.League.Name = name
+	leagueData.League.Standings = tables
+	return []api.Standings{leagueData}, nil
+}
+
+// Gets each team's position after every round played so far, within its group
+func (h *seasonHistory) positions(selected []team) []chartLine {
+	lines := make([]chartLine, len(selected))
+	for i, t := range selected {
+		lines[i].Name = t.Name
+	}
+
+	for round := 1; round <= h.lastPlayedRound(); round++ {
+		ranks := map[int]int{}
+		for _, standing := range h.table(round) {
+			ranks[standing.Team.ID] = standing.Rank
+		}
+		for i, t := range selected {
+			lines[i].Positions = append(lines[i].Positions, ranks[t.ID])
+		}
+	}
+	return lines
+}
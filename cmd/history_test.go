@@ -0,0 +1,144 @@
+/*
+Tests that the API's table is recorded as a snapshot of the right round and
+that past tables come from snapshots or are rebuilt from results.
+*/
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"premcli/api"
+	"strings"
+	"testing"
+)
+
+// Points the store at a temporary directory and sets the followed league
+func useStore(t *testing.T, leagueID int) {
+	oldPath, oldLeague := storePath, league
+	storePath, league = t.TempDir(), leagueID
+	t.Cleanup(func() {
+		storePath, league = oldPath, oldLeague
+	})
+}
+
+// Creates a client of a server that lists a qualifying round before the rounds
+// of the table
+func roundsClient(t *testing.T) *api.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"errors": [], "response": ["Qualifying Round", "Regular Season - 1", "Regular Season - 2", "Regular Season - 3"]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client := api.NewClient("test")
+	client.BaseURL = server.URL
+	return client
+}
+
+// Creates a table whose teams have played the given numbers of matches
+func playedTable(played ...int) []api.Standings {
+	var table []api.Standing
+	for i, count := range played {
+		var standing api.Standing
+		standing.Rank, standing.Team.ID = i+1, i+1
+		standing.All.Played = count
+		table = append(table, standing)
+	}
+
+	var leagueData api.Standings
+	leagueData.League.Standings = [][]api.Standing{table}
+	return []api.Standings{leagueData}
+}
+
+func TestRecordSnapshot(t *testing.T) {
+	useStore(t, 2)
+	client := roundsClient(t)
+
+	// Every team has played twice, so the table is the one after the second
+	// round of the table and not the round before it in the list
+	if err := recordSnapshot(client, playedTable(2, 2), 2024); err != nil {
+		t.Fatal(err)
+	}
+	// Teams have played different numbers of matches, so it isn't recorded
+	if err := recordSnapshot(client, playedTable(3, 2), 2024); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := loadStore(2, 2024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(store.Snapshots) != 1 {
+		t.Fatalf("got %d snapshots, want 1", len(store.Snapshots))
+	}
+	if snap := store.Snapshots[0]; snap.Round != "Regular Season - 2" || snap.Source != sourceStandings {
+		t.Errorf("got a snapshot of %q from %s, want Regular Season - 2 from %s", snap.Round, snap.Source, sourceStandings)
+	}
+}
+
+// Creates the history of a season with two rounds played out of three
+func testHistory(t *testing.T) *seasonHistory {
+	useStore(t, 2)
+
+	first := testFixture(1, 2, "FT", 1, 0)
+	second := testFixture(2, 1, "FT", 3, 0)
+	second.League.Round = "Regular Season - 2"
+	third := testFixture(1, 2, "NS", 0, 0)
+	third.League.Round = "Regular Season - 3"
+
+	history := &seasonHistory{
+		rounds:       []string{"Regular Season - 1", "Regular Season - 2", "Regular Season - 3"},
+		matches:      []api.Match{first, second, third},
+		roundNumbers: map[string]int{"Regular Season - 1": 1, "Regular Season - 2": 2, "Regular Season - 3": 3},
+		store:        &seasonStore{League: 2, Season: 2024},
+	}
+	return history
+}
+
+func TestStandingsAfterRebuilds(t *testing.T) {
+	history := testHistory(t)
+
+	standings, err := history.standingsAfter("2", "Test League")
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := standings[0].League.Standings[0]
+	if got, want := tableOrder(table), []int{2, 1}; !equalOrder(got, want) {
+		t.Errorf("got order %v, want %v", got, want)
+	}
+	if table[0].Group != "Test League after Regular Season - 2" {
+		t.Errorf("got title %q", table[0].Group)
+	}
+
+	// The rebuilt table is kept so it's only worked out once
+	if snap, ok := history.store.snapshot("Regular Season - 2"); !ok || snap.Source != sourceFixtures {
+		t.Errorf("the rebuilt table wasn't stored")
+	}
+}
+
+func TestStandingsAfterSnapshot(t *testing.T) {
+	history := testHistory(t)
+
+	// The snapshot is preferred over the results
+	var alpha, beta api.Standing
+	alpha.Rank, alpha.Team.ID, alpha.Points = 1, 1, 3
+	beta.Rank, beta.Team.ID = 2, 2
+	history.store.putSnapshot(snapshot{Round: "Regular Season - 2", Source: sourceStandings, Standings: []api.Standing{alpha, beta}})
+
+	standings, err := history.standingsAfter("Regular Season - 2", "Test League")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tableOrder(standings[0].League.Standings[0]), []int{1, 2}; !equalOrder(got, want) {
+		t.Errorf("got order %v, want %v", got, want)
+	}
+}
+
+func TestStandingsAfterUnplayed(t *testing.T) {
+	history := testHistory(t)
+
+	_, err := history.standingsAfter("3", "Test League")
+	if err == nil || !strings.Contains(err.Error(), "hasn't been played yet") {
+		t.Errorf("got error %v, want the round not to have been played", err)
+	}
+}
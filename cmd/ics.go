@@ -0,0 +1,119 @@
+/*
+Exports fixtures as an iCalendar (RFC 5545) file so they can be imported into
+or subscribed to from a calendar app.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"premcli/api"
+	"strings"
+	"time"
+)
+
+// Length of the calendar event for a match
+const matchDuration = "PT2H"
+
+// Longest line allowed by RFC 5545, in octets
+const icsLineLength = 75
+
+// Escapes text values as described in RFC 5545 section 3.3.11
+func icsEscape(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+	return replacer.Replace(text)
+}
+
+// Folds a content line longer than 75 octets onto continuation lines,
+// without splitting a multi-byte character
+func icsFold(line string) string {
+	var b strings.Builder
+
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > icsLineLength {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	b.WriteString("\r\n")
+
+	return b.String()
+}
+
+// Summary of a match, with the final score once it has a result. Cancelled and
+// abandoned matches have none.
+func icsSummary(match api.Match) string {
+	if countsInTable(match.Fixture.Status.Short) {
+		return fmt.Sprintf("%s %d-%d %s", match.Teams.Home.Name, match.Goals.Home, match.Goals.Away, match.Teams.Away.Name)
+	}
+	return fmt.Sprintf("%s vs %s", match.Teams.Home.Name, match.Teams.Away.Name)
+}
+
+// Calendar status of a match
+func icsStatus(match api.Match) string {
+	switch match.Fixture.Status.Short {
+	case "CANC", "ABD":
+		return "CANCELLED"
+	case "TBD", "PST":
+		return "TENTATIVE"
+	}
+	return "CONFIRMED"
+}
+
+// Writes the matches as a calendar with one event per match. The UID of each
+// event is based on its fixture ID so importing the file again updates the
+// events instead of duplicating them.
+func writeCalendar(w io.Writer, name string, matches []api.Match) error {
+	now := time.Now().UTC()
+	stamp := now.Format("20060102T150405Z")
+	// SEQUENCE must grow with every revision, minutes since the epoch always do
+	sequence := now.Unix() / 60
+
+	var b strings.Builder
+	line := func(format string, args ...interface{}) {
+		b.WriteString(icsFold(fmt.Sprintf(format, args...)))
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//premcli//premcli//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:%s", icsEscape(name))
+
+	for _, match := range matches {
+		kickoff := match.Kickoff()
+		if kickoff.IsZero() {
+			continue
+		}
+
+		location := match.Fixture.Venue.Name
+		if match.Fixture.Venue.City != "" {
+			location += ", " + match.Fixture.Venue.City
+		}
+
+		line("BEGIN:VEVENT")
+		line("UID:fixture-%d@premcli", match.Fixture.ID)
+		line("DTSTAMP:%s", stamp)
+		line("LAST-MODIFIED:%s", stamp)
+		line("SEQUENCE:%d", sequence)
+		line("DTSTART:%s", kickoff.UTC().Format("20060102T150405Z"))
+		line("DURATION:%s", matchDuration)
+		line("SUMMARY:%s", icsEscape(icsSummary(match)))
+		if location != "" {
+			line("LOCATION:%s", icsEscape(location))
+		}
+		line("DESCRIPTION:%s", icsEscape(fmt.Sprintf("%s\nFixture ID: %d", match.League.Round, match.Fixture.ID)))
+		line("STATUS:%s", icsStatus(match))
+		line("END:VEVENT")
+	}
+
+	line("END:VCALENDAR")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,59 @@
+/*
+Tests that long calendar lines are folded without going over the line length
+or splitting a character.
+*/
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestIcsFold(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  string
+		lines int
+	}{
+		{"short", "SUMMARY:Wolves vs Arsenal", 1},
+		{"exact length", strings.Repeat("a", icsLineLength), 1},
+		{"one over", strings.Repeat("a", icsLineLength+1), 2},
+		{"long", "DESCRIPTION:" + strings.Repeat("x", 200), 3},
+		{"multi-byte", "LOCATION:" + strings.Repeat("é", 80), 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			folded := icsFold(test.line)
+			if !strings.HasSuffix(folded, "\r\n") {
+				t.Fatalf("%q doesn't end with CRLF", folded)
+			}
+
+			lines := strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n")
+			if len(lines) != test.lines {
+				t.Errorf("got %d lines, want %d", len(lines), test.lines)
+			}
+
+			var unfolded strings.Builder
+			for i, line := range lines {
+				if len(line) > icsLineLength {
+					t.Errorf("line %d is %d octets long", i+1, len(line))
+				}
+				if !utf8.ValidString(line) {
+					t.Errorf("line %d splits a character", i+1)
+				}
+				if i > 0 {
+					if !strings.HasPrefix(line, " ") {
+						t.Errorf("continuation line %d doesn't start with a space", i+1)
+					}
+					line = line[1:]
+				}
+				unfolded.WriteString(line)
+			}
+			if unfolded.String() != test.line {
+				t.Errorf("unfolding gives %q, want %q", unfolded.String(), test.line)
+			}
+		})
+	}
+}
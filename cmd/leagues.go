@@ -0,0 +1,175 @@
+/*
+Chooses the league or cup premcli follows, by API-FOOTBALL ID or a friendly
+alias such as epl or laliga, and lists the competitions available.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"premcli/api"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// API-FOOTBALL league ID of the Premier League, followed when none is configured
+const premierLeague = 39
+
+type leagueAlias struct {
+	ID      int
+	Name    string
+	Aliases []string
+}
+
+// Competitions that can be given by name instead of ID
+var leagueAliases = []leagueAlias{
+	{39, "Premier League", []string{"epl", "pl"}},
+	{40, "Championship", []string{"championship", "efl"}},
+	{41, "League One", []string{"league one", "l1"}},
+	{42, "League Two", []string{"league two", "l2"}},
+	{45, "FA Cup", []string{"fa cup"}},
+	{48, "EFL Cup", []string{"league cup", "carabao cup"}},
+	{140, "La Liga", []string{"laliga"}},
+	{78, "Bundesliga", []string{"bundesliga"}},
+	{135, "Serie A", []string{"seriea"}},
+	{61, "Ligue 1", []string{"ligue1"}},
+	{88, "Eredivisie", []string{"eredivisie"}},
+	{94, "Primeira Liga", []string{"primeira", "liga portugal"}},
+	{179, "Scottish Premiership", []string{"spfl"}},
+	{2, "UEFA Champions League", []string{"ucl", "champions league"}},
+	{3, "UEFA Europa League", []string{"uel", "europa league"}},
+	{848, "UEFA Europa Conference League", []string{"uecl", "conference league"}},
+	{253, "Major League Soccer", []string{"mls"}},
+}
+
+var (
+	// League ID every command uses, set by GetConfig
+	league = premierLeague
+	// LEAGUE from the config and the --league flag, which wins
+	leagueSetting string
+	leagueFlag    string
+)
+
+// Compares league names without case, spaces or punctuation
+func normaliseLeague(name string) string {
+	return strings.ReplaceAll(normaliseTeam(name), " ", "")
+}
+
+// Parses a league ID or alias. An empty value is the Premier League.
+func parseLeague(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return premierLeague, nil
+	}
+
+	if id, err := strconv.Atoi(value); err == nil {
+		if id <= 0 {
+			return 0, fmt.Errorf("Invalid league ID %d", id)
+		}
+		return id, nil
+	}
+
+	normalised := normaliseLeague(value)
+	for _, alias := range leagueAliases {
+		if normaliseLeague(alias.Name) == normalised {
+			return alias.ID, nil
+		}
+		for _, name := range alias.Aliases {
+			if normaliseLeague(name) == normalised {
+				return alias.ID, nil
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("Unknown league %q, use an ID from 'premcli leagues' or an alias such as epl, championship, laliga or ucl", value)
+}
+
+// Gets the short alias of a league for listings, if it has one
+func leagueShortAlias(id int) string {
+	for _, alias := range leagueAliases {
+		if alias.ID == id {
+			return alias.Aliases[0]
+		}
+	}
+	return ""
+}
+
+// Gets the name of the followed league, asking the API for leagues without an alias
+func leagueName(client *api.Client) string {
+	for _, alias := range leagueAliases {
+		if alias.ID == league {
+			return alias.Name
+		}
+	}
+
+	if info, err := client.League(league); err == nil {
+		return info.League.Name
+	}
+	return fmt.Sprintf("League %d", league)
+}
+
+var leaguesCmd = &cobra.Command{
+	Use:   "leagues [search]",
+	Short: "Lists and searches the available leagues and cups",
+	Long: `Lists every league and cup available from API-FOOTBALL, or those whose name or country matches a search.
+
+Use the ID or alias with --league, or set it as LEAGUE in the config, to follow that competition.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Get the config
+		err := GetConfig()
+		if err != nil {
+			exitWithError("Error loading config:", err)
+		}
+
+		search := strings.Join(args, " ")
+		if search != "" && len(search) < 3 {
+			exitWithError("Invalid search:", fmt.Errorf("Searches need at least 3 characters"))
+		}
+
+		err = checkQuota(1)
+		if err != nil {
+			exitWithError("Not enough API quota:", err)
+		}
+
+		leagues, err := newClient().Leagues(search)
+		if err != nil {
+			exitWithError("Error fetching and parsing:", err)
+		}
+
+		if len(leagues) == 0 {
+			fmt.Println("No leagues found.")
+			return
+		}
+
+		sort.SliceStable(leagues, func(i, j int) bool {
+			return leagues[i].League.ID < leagues[j].League.ID
+		})
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tName\tType\tCountry\tAlias")
+		for _, info := range leagues {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n",
+				info.League.ID,
+				info.League.Name,
+				info.League.Type,
+				info.Country.Name,
+				leagueShortAlias(info.League.ID),
+			)
+		}
+		w.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(leaguesCmd)
+
+	leaguesCmd.Example = ` # List every league and cup
+premcli leagues
+
+ # Search by name or country
+premcli leagues spain`
+}
@@ -0,0 +1,239 @@
+/*
+Displays the lineups of a fixture given the fixtureID. Can also draw both
+starting elevens on an ASCII pitch.
+*/
+package cmd
+
+import (
+	"fmt"
+	"premcli/api"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var showPitch bool
+
+// Width of the pitch inside its borders
+const pitchWidth = 66
+
+// Longest player label shown on the pitch
+const pitchLabelWidth = 12
+
+// Formats a team's lineup as a list
+func formatLineup(lineup api.Lineup) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s (%s)\n", lineup.Team.Name, lineup.Formation)
+	fmt.Fprintf(&b, "Coach: %s\n", lineup.Coach.Name)
+
+	b.WriteString("Starting XI:\n")
+	for _, player := range lineup.StartXI {
+		fmt.Fprintf(&b, "%3d  %-26s %s\n", player.Player.Number, player.Player.Name, player.Player.Pos)
+	}
+
+	b.WriteString("Substitutes:\n")
+	for _, player := range lineup.Substitutes {
+		fmt.Fprintf(&b, "%3d  %-26s %s\n", player.Player.Number, player.Player.Name, player.Player.Pos)
+	}
+
+	return b.String()
+}
+
+// Short label for a player on the pitch, e.g. "7 Cunha"
+func pitchLabel(player api.LineupPlayer) string {
+	name := player.Player.Name
+	if i := strings.LastIndex(name, " "); i >= 0 {
+		name = name[i+1:]
+	}
+
+	label := fmt.Sprintf("%d %s", player.Player.Number, name)
+	if utf8.RuneCountInString(label) > pitchLabelWidth {
+		label = string([]rune(label)[:pitchLabelWidth])
+	}
+	return label
+}
+
+// Groups the starting XI into rows by their grid position, ordered by column.
+// Row 0 holds the goalkeeper.
+func gridRows(lineup api.Lineup) ([][]api.LineupPlayer, error) {
+	type placed struct {
+		row, col int
+		player   api.LineupPlayer
+	}
+
+	var players []placed
+	maxRow := 0
+	for _, player := range lineup.StartXI {
+		parts := strings.Split(player.Player.Grid, ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("No grid positions available for %s", lineup.Team.Name)
+		}
+		row, rowErr := strconv.Atoi(parts[0])
+		col, colErr := strconv.Atoi(parts[1])
+		if rowErr != nil || colErr != nil || row < 1 {
+			return nil, fmt.Errorf("Invalid grid position %q for %s", player.Player.Grid, player.Player.Name)
+		}
+
+		players = append(players, placed{row, col, player})
+		if row > maxRow {
+			maxRow = row
+		}
+	}
+
+	sort.Slice(players, func(i, j int) bool {
+		return players[i].col < players[j].col
+	})
+
+	rows := make([][]api.LineupPlayer, maxRow)
+	for _, p := range players {
+		rows[p.row-1] = append(rows[p.row-1], p.player)
+	}
+	return rows, nil
+}
+
+// Lays out the players of a row evenly across the pitch
+func pitchLine(players []api.LineupPlayer, mirror bool, highlight bool) string {
+	if len(players) == 0 {
+		return "|" + strings.Repeat(" ", pitchWidth) + "|"
+	}
+
+	slot := pitchWidth / len(players)
+	used := 0
+
+	var b strings.Builder
+	b.WriteString("|")
+	for i := range players {
+		player := players[i]
+		if mirror {
+			player = players[len(players)-1-i]
+		}
+
+		// Crowded rows leave less room than a full label, and a space between
+		// neighbours
+		label := pitchLabel(player)
+		if utf8.RuneCountInString(label) > slot-1 {
+			label = string([]rune(label)[:max(0, slot-1)])
+		}
+		width := utf8.RuneCountInString(label)
+		left := (slot - width) / 2
+
+		b.WriteString(strings.Repeat(" ", left))
+		if highlight {
+			b.WriteString(color.MagentaString(label))
+		} else {
+			b.WriteString(label)
+		}
+		b.WriteString(strings.Repeat(" ", slot-width-left))
+		used += slot
+	}
+	b.WriteString(strings.Repeat(" ", pitchWidth-used) + "|")
+
+	return b.String()
+}
+
+// Draws both starting elevens on a pitch, the away team at the top attacking
+// downwards and the home team at the bottom attacking upwards
+func renderPitch(home, away api.Lineup) (string, error) {
+	homeRows, err := gridRows(home)
+	if err != nil {
+		return "", err
+	}
+	awayRows, err := gridRows(away)
+	if err != nil {
+		return "", err
+	}
+
+	homeFav := isFavTeam(home.Team.ID, favTeam)
+	awayFav := isFavTeam(away.Team.ID, favTeam)
+	border := "+" + strings.Repeat("-", pitchWidth) + "+"
+	blank := pitchLine(nil, false, false)
+
+	var lines []string
+	lines = append(lines, fmt.Sprintf("%s (%s)", away.Team.Name, away.Formation), border)
+	for _, row := range awayRows {
+		lines = append(lines, pitchLine(row, true, awayFav), blank)
+	}
+	lines = append(lines, "|"+strings.Repeat("-", pitchWidth)+"|")
+	for i := len(homeRows) - 1; i >= 0; i-- {
+		lines = append(lines, blank, pitchLine(homeRows[i], false, homeFav))
+	}
+	lines = append(lines, border, fmt.Sprintf("%s (%s)", home.Team.Name, home.Formation))
+
+	return strings.Join(lines, "\n"), nil
+}
+
+var lineupsCmd = &cobra.Command{
+	Use:   "lineups <fixtureID>",
+	Short: "Displays the lineups of a fixture",
+	Long: `Displays the formation, coach, starting XI and substitutes of both teams given a 'fixtureID'.
+
+Lineups are usually announced about an hour before kickoff. Use --pitch to draw the starting elevens on a pitch.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		// Get the config
+		err := GetConfig()
+		if err != nil {
+			exitWithError("Error loading config:", err)
+		}
+
+		fixtureID, err := strconv.Atoi(args[0])
+		if err != nil {
+			exitWithError("Invalid fixture ID:", err)
+		}
+
+		err = checkQuota(1 + extraRequests(false, favTeam != ""))
+		if err != nil {
+			exitWithError("Not enough API quota:", err)
+		}
+
+		client := newClient()
+		lineups, err := client.Lineups(fixtureID)
+		if err != nil {
+			exitWithError("Error fetching and parsing:", err)
+		}
+
+		if len(lineups) == 0 {
+			fmt.Println("The lineups for this fixture haven't been announced yet.")
+			return
+		}
+
+		loadFavTeam(client)
+
+		// The home team is listed first
+		if showPitch && len(lineups) == 2 {
+			pitch, err := renderPitch(lineups[0], lineups[1])
+			if err != nil {
+				exitWithError("Error drawing pitch:", err)
+			}
+			fmt.Println(pitch)
+			return
+		}
+
+		for _, lineup := range lineups {
+			if isFavTeam(lineup.Team.ID, favTeam) {
+				color.Set(color.FgMagenta)
+				fmt.Println(formatLineup(lineup))
+				color.Unset()
+				continue
+			}
+			fmt.Println(formatLineup(lineup))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lineupsCmd)
+
+	lineupsCmd.Flags().BoolVar(&showPitch, "pitch", false, "Draw the starting elevens on a pitch")
+
+	lineupsCmd.Example = ` # Show the lineups for fixture with ID 1234
+premcli lineups 1234
+
+ # Draw the lineups on a pitch
+premcli lineups 1234 --pitch`
+}
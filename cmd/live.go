@@ -1,125 +1,197 @@
 /*
 Displays the live events timeline of a fixture given the fixtureID.
+With --watch the timeline is refreshed until the match has finished.
 */
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
+	"premcli/api"
 	"strconv"
+	"time"
 
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
-type ApiResponseEvents struct {
-	Response []Events `json:"response"`
-}
+var (
+	watch         bool
+	watchInterval time.Duration
+)
 
-type Events struct {
-	Time struct {
-		Elapsed int
-		Extra   int
-	}
-	Team struct {
-		Name string
-	}
-	Player struct {
-		Name string
-	}
-	Assist struct {
-		Name string
-	}
-	Type     string
-	Detail   string
-	Comments string
-}
+// API-FOOTBALL only updates live fixtures every 15 seconds
+const minWatchInterval = 15 * time.Second
 
-type ApiResponseFixtureByID struct {
-	Response []Match `json:"response"`
-}
+// Formats the scoreboard shown above the events
+func formatScoreboard(match api.Match) (string, error) {
+	homeTeam := match.Teams.Home.Name
+	homeScore := match.Goals.Home
+	awayTeam := match.Teams.Away.Name
+	awayScore := match.Goals.Away
+	date := match.Fixture.Date
+	timeElapsed := match.Fixture.Status.Elapsed
 
-// Builds the API URL for retrieving fixtures
-func buildFixtureByIDURL(fixtureID int) string {
-	baseURL := "https://api-football-v1.p.rapidapi.com/v3/fixtures?"
+	// Reformat time so its readable
+	userFriendlyTime, err := FormatTime(date)
+	if err != nil {
+		return "", err
+	}
 
-	fixture := "id=" + strconv.Itoa(fixtureID)
+	// Score Padding
+	const nameScoreWidth = 26
 
-	return baseURL + fixture
+	homePadding := nameScoreWidth - len("[H]") - len(homeTeam) - len(fmt.Sprint(homeScore))
+	awayPadding := nameScoreWidth - len("[A]") - len(awayTeam) - len(fmt.Sprint(awayScore))
+
+	return fmt.Sprintf("Date: %s\n[H] %s%*s%d\n[A] %s%*s%d\nTime Elapsed: %d\nEvents:\n", userFriendlyTime, homeTeam, homePadding, "", homeScore, awayTeam, awayPadding, "", awayScore, timeElapsed), nil
 }
 
-// Builds the API URL for retrieving events
-func buildEventsURL(fixtureID int) string {
-	baseURL := "https://api-football-v1.p.rapidapi.com/v3/fixtures/events?"
+// Formats a single event of the timeline
+func formatEvent(event api.Events) string {
+	eventTime := event.Time.Elapsed
+	eventExtraTime := event.Time.Extra
+	teamName := event.Team.Name
+	playerName := event.Player.Name
+	assistName := event.Assist.Name
+	eventType := event.Type
+	eventDetail := event.Detail
+	eventComment := event.Comments
+
+	// Compute Time
+	extraTimeStr := ""
+	if eventExtraTime > 0 {
+		extraTimeStr = fmt.Sprintf("+%d", eventExtraTime)
+	}
 
-	fixture := "fixture=" + strconv.Itoa(fixtureID)
+	eventSummary := ""
+	if eventType == "Card" {
+		eventSummary = fmt.Sprintf("%d'%s %s\n%s\n%s\n%s\n", eventTime, extraTimeStr, eventDetail, teamName, playerName, eventComment)
+	} else if eventType == "subst" {
+		eventSummary = fmt.Sprintf("%d'%s %s\n%s\nIN\n%s\nOUT\n%s\n", eventTime, extraTimeStr, eventDetail, teamName, playerName, assistName)
+	} else if eventType == "Goal" {
+		eventSummary = fmt.Sprintf("%d'%s GOAL!!!\n%s\nPlayer: %s\nAssist: %s\n%s\n", eventTime, extraTimeStr, teamName, playerName, assistName, eventDetail)
+	} else if eventType == "Var" {
+		eventSummary = fmt.Sprintf("%d'%s %s\n%s\n%s\n%s\n", eventTime, extraTimeStr, eventType, teamName, playerName, eventDetail)
+	}
 
-	return baseURL + fixture
+	return eventSummary
 }
 
-// Gets the events given a fixture ID and parses the JSON
-func getEvents(fixtureID int) ([]Events, error) {
-	url := buildEventsURL(fixtureID)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("Error creating request: %v", err)
+// Gets why watching a match stops, empty while it can still change. Postponed
+// matches and those without a kickoff time won't be played today.
+func watchEnd(status string) string {
+	switch {
+	case status == "PST":
+		return "The match has been postponed."
+	case status == "TBD":
+		return "The match hasn't been given a kickoff time yet."
+	case status == "CANC":
+		return "The match has been cancelled."
+	case status == "ABD":
+		return "The match has been abandoned."
+	case status == "AWD" || status == "WO":
+		return "The match has been awarded."
+	case api.IsFinished(status):
+		return "Full time."
 	}
+	return ""
+}
 
-	req.Header.Add("X-RapidAPI-Key", apiKey)
-	req.Header.Add("X-RapidAPI-Host", "api-football-v1.p.rapidapi.com")
+// Estimates the requests a watch will make, one per poll until the final whistle
+func estimateWatchRequests(match api.Match, interval time.Duration) int {
+	// 90 minutes, half time and some stoppage time
+	remaining := 115 * time.Minute
 
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("Error executing request: %v", err)
+	if api.IsLive(match.Fixture.Status.Short) {
+		remaining -= time.Duration(match.Fixture.Status.Elapsed) * time.Minute
+		if match.Fixture.Status.Elapsed > 45 {
+			remaining -= 15 * time.Minute
+		}
+	} else if kickoff := match.Kickoff(); kickoff.After(time.Now()) {
+		remaining += time.Until(kickoff)
 	}
 
-	defer res.Body.Close()
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, fmt.Errorf("Error reading response: %v", err)
+	if remaining < interval {
+		return 1
 	}
+	return int(remaining / interval)
+}
 
-	var responseData ApiResponseEvents
-	err = json.Unmarshal(body, &responseData)
-	if err != nil {
-		return nil, fmt.Errorf("Error parsing JSON response: %v", err)
+// Slows polling down as the daily quota runs low so a watch doesn't use it all up
+func watchBackoff(interval time.Duration) time.Duration {
+	quota, ok := api.LoadQuota(quotaPath)
+	if !ok || !quota.Current() {
+		return interval
 	}
 
-	return responseData.Response, nil
+	switch {
+	case quota.Remaining < lowQuotaThreshold:
+		return interval * 4
+	case quota.Remaining < 2*lowQuotaThreshold:
+		return interval * 2
+	}
+	return interval
 }
 
-// Gets the fixture information given a fixture ID and parses the JSON
-func getFixtureByID(fixtureID int) ([]Match, error) {
-	url := buildFixtureByIDURL(fixtureID)
+// Redraws the fixture until it is over, highlighting events that are new since the last poll
+func watchFixture(client *api.Client, match api.Match, interval time.Duration) {
+	seen := 0
 
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("Error creating request: %v", err)
+	// Every poll needs fresh data, the responses are still cached for other commands
+	if client.CacheMode == api.CacheDefault {
+		client.CacheMode = api.CacheRefresh
 	}
 
-	req.Header.Add("X-RapidAPI-Key", apiKey)
-	req.Header.Add("X-RapidAPI-Host", "api-football-v1.p.rapidapi.com")
+	for {
+		scoreboard, err := formatScoreboard(match)
+		if err != nil {
+			exitWithError("Error formatting fixture:", err)
+		}
 
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("Error executing request: %v", err)
-	}
+		// Clear the screen and move the cursor to the top
+		fmt.Print("\033[H\033[2J")
+		fmt.Print(scoreboard)
+		fmt.Println()
 
-	defer res.Body.Close()
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, fmt.Errorf("Error reading response: %v", err)
-	}
+		if len(match.Statistics) == 2 {
+			fmt.Println(formatStatsChart(match.Statistics[0], match.Statistics[1], liveStatRows))
+		}
 
-	var responseData ApiResponseFixtureByID
-	err = json.Unmarshal(body, &responseData)
-	if err != nil {
-		return nil, fmt.Errorf("Error parsing JSON response: %v", err)
-	}
+		for i, event := range match.Events {
+			if i >= seen && seen > 0 {
+				color.Set(color.FgGreen)
+				fmt.Println("NEW " + formatEvent(event))
+				color.Unset()
+				continue
+			}
+			fmt.Println(formatEvent(event))
+		}
+		seen = len(match.Events)
+
+		if end := watchEnd(match.Fixture.Status.Short); end != "" {
+			fmt.Println(end)
+			return
+		}
 
-	return responseData.Response, nil
+		quota, ok := api.LoadQuota(quotaPath)
+		if ok && quota.Current() && quota.Remaining == 0 && !force {
+			fmt.Println("Stopped watching, the daily API quota has been used up.")
+			return
+		}
+
+		next := watchBackoff(interval)
+		if next != interval {
+			fmt.Printf("Quota is running low (%d requests left), refreshing every %s.\n", quota.Remaining, next)
+		} else {
+			fmt.Printf("Last updated %s, refreshing every %s. Press Ctrl+C to stop.\n", time.Now().Format("03:04:05 PM"), next)
+		}
+		time.Sleep(next)
+
+		match, err = client.FixtureByID(match.Fixture.ID)
+		if err != nil {
+			exitWithError("Error fetching and parsing:", err)
+		}
+	}
 }
 
 var liveCmd = &cobra.Command{
@@ -127,98 +199,79 @@ var liveCmd = &cobra.Command{
 	Short: "Tracks the live events of a fixture",
 	Long: `Tracks the live events of a fixture given a 'fixtureID'.
 
-To obtain the 'fixtureID', use 'premcli fixtures' to display the fixtures with their appropriate 'fixtureID'.`,
-	Args: cobra.ExactArgs(1),
+To obtain the 'fixtureID', use 'premcli fixtures' to display the fixtures with their appropriate 'fixtureID'.
+
+Use --watch to keep refreshing the events until the match has finished.`,
+	Annotations: map[string]string{machineOutputAnnotation: ""},
+	Args:        cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		// Get the config
 		err := GetConfig()
 		if err != nil {
-			fmt.Println("Error loading config:", err)
-			return
+			exitWithError("Error loading config:", err)
 		}
 
 		fixtureID, err := strconv.Atoi(args[0])
 		if err != nil {
-			return
+			exitWithError("Invalid fixture ID:", err)
 		}
 
-		// Get events
-		events, err := getEvents(fixtureID)
-		if err != nil {
-			fmt.Println("Error fetching and parsing:", err)
-			return
+		if watch && machineOutput() {
+			exitWithError("Invalid flags:", fmt.Errorf("--watch only supports text output"))
 		}
 
-		// Get fixture information
-		match, err := getFixtureByID(fixtureID)
-		if err != nil {
-			fmt.Println("Error fetching and parsing:", err)
-			return
+		if watch && watchInterval < minWatchInterval {
+			exitWithError("Invalid interval:", fmt.Errorf("The interval must be at least %s", minWatchInterval))
 		}
 
-		homeTeam := match[0].Teams.Home.Name
-		homeScore := match[0].Goals.Home
-		awayTeam := match[0].Teams.Away.Name
-		awayScore := match[0].Goals.Away
-		date := match[0].Fixture.Date
-		timeElapsed := match[0].Fixture.Status.Elapsed
-
-		// Reformat time so its readable
-		userFriendlyTime, err := FormatTime(date)
+		// The fixture includes its events
+		err = checkQuota(1)
 		if err != nil {
-			fmt.Println(err)
-			return
+			exitWithError("Not enough API quota:", err)
 		}
 
-		// Score Padding
-		const nameScoreWidth = 26
-		const vsWidth = 25
+		client := newClient()
 
-		homePadding := nameScoreWidth - len("[H]") - len(homeTeam) - len(fmt.Sprint(homeScore))
-		awayPadding := nameScoreWidth - len("[A]") - len(awayTeam) - len(fmt.Sprint(awayScore))
+		// Get fixture information
+		match, err := client.FixtureByID(fixtureID)
+		if err != nil {
+			exitWithError("Error fetching and parsing:", err)
+		}
 
-		// Fixture Info
-		matchDisplay := ""
-		matchDisplay = fmt.Sprintf("Date: %s\n[H] %s%*s%d\n[A] %s%*s%d\nTime Elapsed: %d\nEvents:\n", userFriendlyTime, homeTeam, homePadding, "", homeScore, awayTeam, awayPadding, "", awayScore, timeElapsed)
+		// Watch mode polls the fixture, which includes its events
+		if watch {
+			if watchEnd(match.Fixture.Status.Short) == "" {
+				err = checkQuota(estimateWatchRequests(match, watchInterval))
+				if err != nil {
+					exitWithError("Not enough API quota for --watch, try a longer --interval:", err)
+				}
+			}
 
-		// Events Info
-		var eventsArr []string
+			watchFixture(client, match, watchInterval)
+			return
+		}
 
-		// Loop through each event and store it in output array
-		for _, event := range events {
-			eventTime := event.Time.Elapsed
-			eventExtraTime := event.Time.Extra
-			teamName := event.Team.Name
-			playerName := event.Player.Name
-			assistName := event.Assist.Name
-			eventType := event.Type
-			eventDetail := event.Detail
-			eventComment := event.Comments
-
-			// Compute Time
-			extraTimeStr := ""
-			if eventExtraTime > 0 {
-				extraTimeStr = fmt.Sprintf("+%d", eventExtraTime)
-			}
+		events := match.Events
 
-			eventSummary := ""
-			if eventType == "Card" {
-				eventSummary = fmt.Sprintf("%d'%s %s\n%s\n%s\n%s\n", eventTime, extraTimeStr, eventDetail, teamName, playerName, eventComment)
-			} else if eventType == "subst" {
-				eventSummary = fmt.Sprintf("%d'%s %s\n%s\nIN\n%s\nOUT\n%s\n", eventTime, extraTimeStr, eventDetail, teamName, playerName, assistName)
-			} else if eventType == "Goal" {
-				eventSummary = fmt.Sprintf("%d'%s GOAL!!!\n%s\nPlayer: %s\nAssist: %s\n%s\n", eventTime, extraTimeStr, teamName, playerName, assistName, eventDetail)
-			} else if eventType == "Var" {
-				eventSummary = fmt.Sprintf("%d'%s %s\n%s\n%s\n%s\n", eventTime, extraTimeStr, eventType, teamName, playerName, eventDetail)
+		if machineOutput() {
+			err = writeLive(match, events)
+			if err != nil {
+				exitWithError("Error writing output:", err)
 			}
+			return
+		}
 
-			eventsArr = append(eventsArr, eventSummary)
+		// Fixture Info
+		matchDisplay, err := formatScoreboard(match)
+		if err != nil {
+			fmt.Println(err)
+			return
 		}
 
 		// Display Fixture info and events
 		fmt.Println(matchDisplay)
-		for _, event := range eventsArr {
-			fmt.Println(event)
+		for _, event := range events {
+			fmt.Println(formatEvent(event))
 		}
 	},
 }
@@ -226,6 +279,12 @@ To obtain the 'fixtureID', use 'premcli fixtures' to display the fixtures with t
 func init() {
 	rootCmd.AddCommand(liveCmd)
 
+	liveCmd.Flags().BoolVarP(&watch, "watch", "w", false, "Keep refreshing the events until the match has finished")
+	liveCmd.Flags().DurationVarP(&watchInterval, "interval", "i", 30*time.Second, "How often to refresh in watch mode")
+
 	liveCmd.Example = ` # Retrieve live events for fixture with ID 1234
-premcli live 1234`
+premcli live 1234
+
+ # Follow the fixture until full time, refreshing every minute
+premcli live 1234 --watch --interval 1m`
 }
@@ -0,0 +1,70 @@
+/*
+Tests when watching a match stops and how many requests a watch is expected
+to make.
+*/
+package cmd
+
+import (
+	"premcli/api"
+	"testing"
+	"time"
+)
+
+func TestWatchEnd(t *testing.T) {
+	tests := []struct {
+		status string
+		ends   bool
+	}{
+		{"NS", false},
+		{"1H", false},
+		{"HT", false},
+		{"SUSP", false},
+		{"PST", true},
+		{"TBD", true},
+		{"CANC", true},
+		{"ABD", true},
+		{"AWD", true},
+		{"WO", true},
+		{"FT", true},
+		{"PEN", true},
+	}
+
+	for _, test := range tests {
+		if ends := watchEnd(test.status) != ""; ends != test.ends {
+			t.Errorf("got watching %s ends %v, want %v", test.status, ends, test.ends)
+		}
+	}
+}
+
+// Creates a fixture with a status, minutes played and kickoff time
+func watchedMatch(status string, elapsed int, kickoff time.Time) api.Match {
+	match := testFixture(1, 2, status, 0, 0)
+	match.Fixture.Status.Elapsed = elapsed
+	match.Fixture.Date = kickoff.Format(time.RFC3339)
+	return match
+}
+
+func TestEstimateWatchRequests(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name  string
+		match api.Match
+		min   int
+		max   int
+	}{
+		{"first half", watchedMatch("1H", 10, now.Add(-10*time.Minute)), 105, 105},
+		{"second half", watchedMatch("2H", 60, now.Add(-75*time.Minute)), 40, 40},
+		{"stoppage time", watchedMatch("2H", 100, now.Add(-2*time.Hour)), 1, 1},
+		{"kickoff late", watchedMatch("NS", 0, now.Add(-5*time.Minute)), 115, 115},
+		{"kickoff in an hour", watchedMatch("NS", 0, now.Add(time.Hour)), 174, 175},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := estimateWatchRequests(test.match, time.Minute); got < test.min || got > test.max {
+				t.Errorf("got %d requests, want %d to %d", got, test.min, test.max)
+			}
+		})
+	}
+}
@@ -0,0 +1,317 @@
+/*
+Machine-readable output for scripts and dashboards. Commands that support
+--output convert their data to the schemas below, which only ever gain fields.
+Goals are null before kickoff and kickoff times are RFC 3339.
+*/
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"premcli/api"
+	"strconv"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputYAML = "yaml"
+	outputCSV  = "csv"
+)
+
+var outputFormat string
+
+// Where machine-readable output is written
+var outputWriter io.Writer = os.Stdout
+
+// Annotation set on commands that support machine-readable output
+const machineOutputAnnotation = "machine-output"
+
+type teamScoreOutput struct {
+	Team  string `json:"team" yaml:"team"`
+	Goals *int   `json:"goals" yaml:"goals"`
+}
+
+type fixtureOutput struct {
+	ID      int             `json:"id" yaml:"id"`
+	Round   string          `json:"round" yaml:"round"`
+	Kickoff string          `json:"kickoff" yaml:"kickoff"`
+	Status  string          `json:"status" yaml:"status"`
+	Elapsed int             `json:"elapsed" yaml:"elapsed"`
+	Home    teamScoreOutput `json:"home" yaml:"home"`
+	Away    teamScoreOutput `json:"away" yaml:"away"`
+}
+
+type recordOutput struct {
+	Played       int `json:"played" yaml:"played"`
+	Won          int `json:"won" yaml:"won"`
+	Drawn        int `json:"drawn" yaml:"drawn"`
+	Lost         int `json:"lost" yaml:"lost"`
+	GoalsFor     int `json:"goals_for" yaml:"goals_for"`
+	GoalsAgainst int `json:"goals_against" yaml:"goals_against"`
+}
+
+type standingOutput struct {
+	Rank           int          `json:"rank" yaml:"rank"`
+	Team           string       `json:"team" yaml:"team"`
+	Played         int          `json:"played" yaml:"played"`
+	Won            int          `json:"won" yaml:"won"`
+	Drawn          int          `json:"drawn" yaml:"drawn"`
+	Lost           int          `json:"lost" yaml:"lost"`
+	GoalsFor       int          `json:"goals_for" yaml:"goals_for"`
+	GoalsAgainst   int          `json:"goals_against" yaml:"goals_against"`
+	GoalDifference int          `json:"goal_difference" yaml:"goal_difference"`
+	Points         int          `json:"points" yaml:"points"`
+	Form           string       `json:"form" yaml:"form"`
+	Group          string       `json:"group" yaml:"group"`
+	Description    string       `json:"description" yaml:"description"`
+	Home           recordOutput `json:"home" yaml:"home"`
+	Away           recordOutput `json:"away" yaml:"away"`
+}
+
+type eventOutput struct {
+	Minute   int    `json:"minute" yaml:"minute"`
+	Extra    int    `json:"extra" yaml:"extra"`
+	Team     string `json:"team" yaml:"team"`
+	Type     string `json:"type" yaml:"type"`
+	Detail   string `json:"detail" yaml:"detail"`
+	Player   string `json:"player" yaml:"player"`
+	Assist   string `json:"assist" yaml:"assist"`
+	Comments string `json:"comments" yaml:"comments"`
+}
+
+type liveOutput struct {
+	Fixture fixtureOutput `json:"fixture" yaml:"fixture"`
+	Events  []eventOutput `json:"events" yaml:"events"`
+}
+
+// Checks if a machine-readable format was chosen
+func machineOutput() bool {
+	return outputFormat != outputText
+}
+
+// Validates --output before any command runs and turns colour off for
+// machine-readable formats
+func checkOutputFormat(cmd *cobra.Command) error {
+	switch outputFormat {
+	case outputText:
+		return nil
+	case outputJSON, outputYAML, outputCSV:
+	default:
+		return fmt.Errorf("Unknown output format %q, use text, json, yaml or csv", outputFormat)
+	}
+
+	if _, ok := cmd.Annotations[machineOutputAnnotation]; !ok {
+		return fmt.Errorf("'premcli %s' doesn't support --output %s", cmd.Name(), outputFormat)
+	}
+
+	color.NoColor = true
+	return nil
+}
+
+// Writes value as JSON or YAML, or rows as CSV
+func writeOutput(value interface{}, rows [][]string) error {
+	switch outputFormat {
+	case outputJSON:
+		encoder := json.NewEncoder(outputWriter)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	case outputYAML:
+		encoder := yaml.NewEncoder(outputWriter)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(value); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case outputCSV:
+		return csv.NewWriter(outputWriter).WriteAll(rows)
+	}
+	return nil
+}
+
+// Goals are only known while a match is being played and once it has a
+// result. Cancelled and abandoned matches have none.
+func goalsOutput(match api.Match, goals int) *int {
+	if !api.IsLive(match.Fixture.Status.Short) && !countsInTable(match.Fixture.Status.Short) {
+		return nil
+	}
+	return &goals
+}
+
+func toFixtureOutput(match api.Match) fixtureOutput {
+	return fixtureOutput{
+		ID:      match.Fixture.ID,
+		Round:   match.League.Round,
+		Kickoff: match.Fixture.Date,
+		Status:  match.Fixture.Status.Short,
+		Elapsed: match.Fixture.Status.Elapsed,
+		Home:    teamScoreOutput{Team: match.Teams.Home.Name, Goals: goalsOutput(match, match.Goals.Home)},
+		Away:    teamScoreOutput{Team: match.Teams.Away.Name, Goals: goalsOutput(match, match.Goals.Away)},
+	}
+}
+
+func toEventOutput(event api.Events) eventOutput {
+	return eventOutput{
+		Minute:   event.Time.Elapsed,
+		Extra:    event.Time.Extra,
+		Team:     event.Team.Name,
+		Type:     event.Type,
+		Detail:   event.Detail,
+		Player:   event.Player.Name,
+		Assist:   event.Assist.Name,
+		Comments: event.Comments,
+	}
+}
+
+func toRecordOutput(record api.Record) recordOutput {
+	return recordOutput{
+		Played:       record.Played,
+		Won:          record.Win,
+		Drawn:        record.Draw,
+		Lost:         record.Lose,
+		GoalsFor:     record.Goals.For,
+		GoalsAgainst: record.Goals.Against,
+	}
+}
+
+// Flattens a record into CSV cells
+func csvRecord(record recordOutput) []string {
+	return []string{
+		strconv.Itoa(record.Played),
+		strconv.Itoa(record.Won),
+		strconv.Itoa(record.Drawn),
+		strconv.Itoa(record.Lost),
+		strconv.Itoa(record.GoalsFor),
+		strconv.Itoa(record.GoalsAgainst),
+	}
+}
+
+// Formats nullable goals for CSV, empty when unknown
+func csvGoals(goals *int) string {
+	if goals == nil {
+		return ""
+	}
+	return strconv.Itoa(*goals)
+}
+
+// Writes fixtures in the chosen machine-readable format
+func writeFixtures(matches []api.Match) error {
+	fixtures := []fixtureOutput{}
+	rows := [][]string{{"id", "round", "kickoff", "status", "elapsed", "home", "home_goals", "away", "away_goals"}}
+
+	for _, match := range matches {
+		fixture := toFixtureOutput(match)
+		fixtures = append(fixtures, fixture)
+		rows = append(rows, []string{
+			strconv.Itoa(fixture.ID),
+			fixture.Round,
+			fixture.Kickoff,
+			fixture.Status,
+			strconv.Itoa(fixture.Elapsed),
+			fixture.Home.Team,
+			csvGoals(fixture.Home.Goals),
+			fixture.Away.Team,
+			csvGoals(fixture.Away.Goals),
+		})
+	}
+
+	return writeOutput(fixtures, rows)
+}
+
+// Writes standings rows in the chosen machine-readable format
+func writeStandings(standings []api.Standings) error {
+	rowsOutput := []standingOutput{}
+	rows := [][]string{{"rank", "team", "played", "won", "drawn", "lost", "goals_for", "goals_against", "goal_difference", "points", "form", "group", "description",
+		"home_played", "home_won", "home_drawn", "home_lost", "home_goals_for", "home_goals_against",
+		"away_played", "away_won", "away_drawn", "away_lost", "away_goals_for", "away_goals_against"}}
+
+	for _, leagueData := range standings {
+		for _, standingsRow := range leagueData.League.Standings {
+			for _, standing := range standingsRow {
+				row := standingOutput{
+					Rank:           standing.Rank,
+					Team:           standing.Team.Name,
+					Played:         standing.All.Played,
+					Won:            standing.All.Win,
+					Drawn:          standing.All.Draw,
+					Lost:           standing.All.Lose,
+					GoalsFor:       standing.All.Goals.For,
+					GoalsAgainst:   standing.All.Goals.Against,
+					GoalDifference: standing.GoalsDiff,
+					Points:         standing.Points,
+					Form:           standing.Form,
+					Group:          standing.Group,
+					Description:    standing.Description,
+					Home:           toRecordOutput(standing.Home),
+					Away:           toRecordOutput(standing.Away),
+				}
+				rowsOutput = append(rowsOutput, row)
+				csvRow := []string{
+					strconv.Itoa(row.Rank),
+					row.Team,
+					strconv.Itoa(row.Played),
+					strconv.Itoa(row.Won),
+					strconv.Itoa(row.Drawn),
+					strconv.Itoa(row.Lost),
+					strconv.Itoa(row.GoalsFor),
+					strconv.Itoa(row.GoalsAgainst),
+					strconv.Itoa(row.GoalDifference),
+					strconv.Itoa(row.Points),
+					row.Form,
+					row.Group,
+					row.Description,
+				}
+				csvRow = append(csvRow, csvRecord(row.Home)...)
+				csvRow = append(csvRow, csvRecord(row.Away)...)
+				rows = append(rows, csvRow)
+			}
+		}
+	}
+
+	return writeOutput(rowsOutput, rows)
+}
+
+// Writes a fixture and its event timeline in the chosen machine-readable format.
+// CSV has one row per event, each starting with the fixture's score and status,
+// and a row with empty event fields if there are no events yet.
+func writeLive(match api.Match, events []api.Events) error {
+	live := liveOutput{Fixture: toFixtureOutput(match), Events: []eventOutput{}}
+	rows := [][]string{{"fixture_id", "status", "elapsed", "home", "home_goals", "away", "away_goals",
+		"minute", "extra", "team", "type", "detail", "player", "assist", "comments"}}
+
+	fixture := []string{
+		strconv.Itoa(live.Fixture.ID),
+		live.Fixture.Status,
+		strconv.Itoa(live.Fixture.Elapsed),
+		live.Fixture.Home.Team,
+		csvGoals(live.Fixture.Home.Goals),
+		live.Fixture.Away.Team,
+		csvGoals(live.Fixture.Away.Goals),
+	}
+	for _, event := range events {
+		output := toEventOutput(event)
+		live.Events = append(live.Events, output)
+		rows = append(rows, append(append([]string{}, fixture...),
+			strconv.Itoa(output.Minute),
+			strconv.Itoa(output.Extra),
+			output.Team,
+			output.Type,
+			output.Detail,
+			output.Player,
+			output.Assist,
+			output.Comments,
+		))
+	}
+	if len(events) == 0 {
+		rows = append(rows, append(fixture, make([]string, 8)...))
+	}
+
+	return writeOutput(live, rows)
+}
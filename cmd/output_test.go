@@ -0,0 +1,162 @@
+/*
+Tests the machine-readable output schemas, in particular that goals are null
+until they are known.
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"premcli/api"
+	"testing"
+)
+
+// Sets the output format for a test and gets what is written
+func useOutput(t *testing.T, format string) *bytes.Buffer {
+	oldFormat, oldWriter := outputFormat, outputWriter
+	var buffer bytes.Buffer
+	outputFormat, outputWriter = format, &buffer
+	t.Cleanup(func() {
+		outputFormat, outputWriter = oldFormat, oldWriter
+	})
+	return &buffer
+}
+
+// Reads CSV output, failing the test if it can't be parsed
+func readCSV(t *testing.T, buffer *bytes.Buffer) [][]string {
+	rows, err := csv.NewReader(buffer).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+func TestGoalsOutput(t *testing.T) {
+	tests := []struct {
+		status string
+		known  bool
+	}{
+		{"NS", false},
+		{"PST", false},
+		{"CANC", false},
+		{"ABD", false},
+		{"1H", true},
+		{"HT", true},
+		{"FT", true},
+		{"PEN", true},
+		{"AWD", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.status, func(t *testing.T) {
+			fixture := toFixtureOutput(testFixture(1, 2, test.status, 2, 1))
+			if known := fixture.Home.Goals != nil && fixture.Away.Goals != nil; known != test.known {
+				t.Fatalf("got goals known %v, want %v", known, test.known)
+			}
+			if test.known && (*fixture.Home.Goals != 2 || *fixture.Away.Goals != 1) {
+				t.Errorf("got %d-%d, want 2-1", *fixture.Home.Goals, *fixture.Away.Goals)
+			}
+		})
+	}
+}
+
+func TestWriteFixturesJSON(t *testing.T) {
+	buffer := useOutput(t, outputJSON)
+
+	matches := []api.Match{testFixture(1, 2, "FT", 3, 0), testFixture(3, 4, "NS", 0, 0)}
+	if err := writeFixtures(matches); err != nil {
+		t.Fatal(err)
+	}
+
+	var fixtures []map[string]interface{}
+	if err := json.Unmarshal(buffer.Bytes(), &fixtures); err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) != 2 {
+		t.Fatalf("got %d fixtures, want 2", len(fixtures))
+	}
+	for _, field := range []string{"id", "round", "kickoff", "status", "elapsed", "home", "away"} {
+		if _, exists := fixtures[0][field]; !exists {
+			t.Errorf("fixture has no %q field", field)
+		}
+	}
+
+	home := fixtures[0]["home"].(map[string]interface{})
+	if home["team"] != "Alpha" || home["goals"] != 3.0 {
+		t.Errorf("got home %v, want Alpha with 3 goals", home)
+	}
+	upcoming := fixtures[1]["home"].(map[string]interface{})
+	if goals, exists := upcoming["goals"]; !exists || goals != nil {
+		t.Errorf("got goals %v before kickoff, want null", goals)
+	}
+}
+
+func TestWriteFixturesCSV(t *testing.T) {
+	buffer := useOutput(t, outputCSV)
+
+	if err := writeFixtures([]api.Match{testFixture(1, 2, "NS", 0, 0)}); err != nil {
+		t.Fatal(err)
+	}
+
+	rows := readCSV(t, buffer)
+	if len(rows) != 2 || len(rows[1]) != len(rows[0]) {
+		t.Fatalf("got rows %v, want a header and a row of the same width", rows)
+	}
+	if rows[1][5] != "Alpha" || rows[1][6] != "" || rows[1][8] != "" {
+		t.Errorf("got row %v, want Alpha and empty goals", rows[1])
+	}
+}
+
+func TestWriteLiveCSV(t *testing.T) {
+	match := testFixture(1, 2, "2H", 1, 0)
+	match.Fixture.ID = 7
+	match.Fixture.Status.Elapsed = 60
+
+	t.Run("without events", func(t *testing.T) {
+		buffer := useOutput(t, outputCSV)
+		if err := writeLive(match, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		rows := readCSV(t, buffer)
+		if len(rows) != 2 {
+			t.Fatalf("got %d rows, want a header and the fixture", len(rows))
+		}
+		want := []string{"7", "2H", "60", "Alpha", "1", "Beta", "0", "", "", "", "", "", "", "", ""}
+		if !equalRow(rows[1], want) {
+			t.Errorf("got row %v, want %v", rows[1], want)
+		}
+	})
+
+	t.Run("with events", func(t *testing.T) {
+		buffer := useOutput(t, outputCSV)
+		goal := api.Events{Type: "Goal", Detail: "Normal Goal"}
+		goal.Time.Elapsed = 55
+		goal.Team.Name = "Alpha"
+		if err := writeLive(match, []api.Events{goal, goal}); err != nil {
+			t.Fatal(err)
+		}
+
+		rows := readCSV(t, buffer)
+		if len(rows) != 3 {
+			t.Fatalf("got %d rows, want a header and 2 events", len(rows))
+		}
+		want := []string{"7", "2H", "60", "Alpha", "1", "Beta", "0", "55", "0", "Alpha", "Goal", "Normal Goal", "", "", ""}
+		if !equalRow(rows[2], want) {
+			t.Errorf("got row %v, want %v", rows[2], want)
+		}
+	})
+}
+
+func equalRow(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
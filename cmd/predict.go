@@ -0,0 +1,228 @@
+/*
+Predicts how the season will end by simulating the fixtures still to be played.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"premcli/api"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	simulations int
+	seed        int64
+)
+
+// A qualification or relegation zone and the ranks in it
+type tableZone struct {
+	Label string
+	Ranks []int
+}
+
+// Gets the zones of a table from the API's descriptions, in table order
+func tableZones(table []api.Standing) []tableZone {
+	var zones []tableZone
+	index := map[string]int{}
+	for _, standing := range table {
+		label := zoneLabel(standing.Description)
+		if label == "" {
+			continue
+		}
+		if _, exists := index[label]; !exists {
+			index[label] = len(zones)
+			zones = append(zones, tableZone{Label: label})
+		}
+		zones[index[label]].Ranks = append(zones[index[label]].Ranks, standing.Rank)
+	}
+	return zones
+}
+
+// Shortens a zone label for a column heading, e.g. "Promotion - Champions
+// League" becomes "Champions League" and "Relegation - Championship" becomes
+// "Relegation"
+func zoneHeading(label string) string {
+	kind, detail, found := strings.Cut(label, " - ")
+	if !found {
+		return label
+	}
+	if kind == "Promotion" {
+		return detail
+	}
+	return kind
+}
+
+// Formats a chance as a whole percentage. Chances that round to 0 or 100
+// without being certain show as "<1" and ">99".
+func formatChance(count, total int) string {
+	percent := float64(count) * 100 / float64(total)
+	switch {
+	case count == 0:
+		return "0"
+	case count == total:
+		return "100"
+	case percent < 0.5:
+		return "<1"
+	case percent >= 99.5:
+		return ">99"
+	}
+	return strconv.Itoa(int(percent + 0.5))
+}
+
+// Prints each team's points now and expected at the end of the season, with
+// their chances of winning the league and of finishing in each zone
+func printPredictionSummary(title string, prediction seasonPrediction, zones []tableZone) {
+	printTableTitle(title)
+
+	header := []string{"Rank", "Club", "Pts", "xPts", "Title %"}
+	for _, zone := range zones {
+		header = append(header, zoneHeading(zone.Label)+" %")
+	}
+	t := newTable(header...)
+
+	for _, standing := range prediction.Table {
+		paint := rowPainter(standing, "")
+		id := standing.Team.ID
+
+		row := []string{
+			paint(standing.Rank),
+			paint(standing.Team.Name),
+			paint(standing.Points),
+			paint(fmt.Sprintf("%.1f", prediction.expectedPoints(id))),
+			paint(formatChance(prediction.Finishes[id][0], prediction.Simulations)),
+		}
+		for _, zone := range zones {
+			row = append(row, paint(formatChance(prediction.finishesIn(id, zone.Ranks), prediction.Simulations)))
+		}
+		t.AddRow(row...)
+	}
+
+	t.Render(os.Stdout)
+}
+
+// Prints each team's chance of finishing in every position. Positions a team
+// never finished in are left blank.
+func printPositionChances(title string, prediction seasonPrediction) {
+	printTableTitle(title)
+
+	header := []string{"Club"}
+	for rank := 1; rank <= len(prediction.Table); rank++ {
+		header = append(header, strconv.Itoa(rank))
+	}
+	t := newTable(header...)
+
+	for _, standing := range prediction.Table {
+		paint := rowPainter(standing, "")
+
+		row := []string{paint(standing.Team.Name)}
+		for _, count := range prediction.Finishes[standing.Team.ID] {
+			chance := ""
+			if count > 0 {
+				chance = formatChance(count, prediction.Simulations)
+			}
+			row = append(row, paint(chance))
+		}
+		t.AddRow(row...)
+	}
+
+	t.Render(os.Stdout)
+}
+
+var predictCmd = &cobra.Command{
+	Use:   "predict",
+	Short: "Predicts outcomes from this season's results",
+}
+
+var predictSeasonCmd = &cobra.Command{
+	Use:   "season",
+	Short: "Predicts where each team will finish",
+	Long: `Predicts where each team will finish the season.
+
+Each team's attack and defence are worked out from this season's results, then the remaining fixtures are simulated thousands of times. Shows the expected points of each team, their chances of finishing in each qualification and relegation zone and in every position.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Get the config
+		err := GetConfig()
+		if err != nil {
+			exitWithError("Error loading config:", err)
+		}
+
+		if simulations <= 0 {
+			exitWithError("Invalid flags:", fmt.Errorf("--simulations must be at least 1"))
+		}
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+
+		// The season's fixtures and the current table
+		err = checkQuota(2 + extraRequests(true, favTeam != ""))
+		if err != nil {
+			exitWithError("Not enough API quota:", err)
+		}
+
+		client := newClient()
+		season := getSeason(client)
+
+		matches, err := client.Fixtures(league, season, "", timezone)
+		if err != nil {
+			exitWithError("Error fetching and parsing:", err)
+		}
+		// Qualifying rounds and play-offs aren't part of the table, so they
+		// neither rate the teams nor get simulated
+		matches = tableMatches(matches)
+		if len(matches) == 0 {
+			exitWithError("Error predicting season:", fmt.Errorf("No fixtures found for the %d season", season))
+		}
+
+		// The API's table gives the points teams start from and the zones
+		standings, err := client.Standings(league, season)
+		if err != nil {
+			exitWithError("Error fetching and parsing:", err)
+		}
+		if len(standings) > 1 || (len(standings) == 1 && len(standings[0].League.Standings) > 1) {
+			exitWithError("Error predicting season:", fmt.Errorf("Predictions only work for leagues with a single table"))
+		}
+
+		var current []api.Standing
+		if len(standings) == 1 {
+			current = standings[0].League.Standings[0]
+		}
+		prediction := simulateSeason(matches, current, simulations, seed)
+		zones := tableZones(current)
+
+		// Most expected points first
+		sort.SliceStable(prediction.Table, func(i, j int) bool {
+			return prediction.expectedPoints(prediction.Table[i].Team.ID) > prediction.expectedPoints(prediction.Table[j].Team.ID)
+		})
+
+		loadFavTeam(client)
+
+		name := leagueName(client)
+		printPredictionSummary(fmt.Sprintf("%s %d predicted", name, season), prediction, zones)
+		fmt.Println()
+		printPositionChances("Finishing position chances (%)", prediction)
+		fmt.Printf("\n%d simulations, seed %d\n", prediction.Simulations, seed)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(predictCmd)
+	predictCmd.AddCommand(predictSeasonCmd)
+
+	predictSeasonCmd.Flags().IntVarP(&simulations, "simulations", "n", 10000, "Number of times to simulate the rest of the season")
+	predictSeasonCmd.Flags().Int64Var(&seed, "seed", 0, "Seed for the simulations, to repeat a prediction (default random)")
+
+	predictSeasonCmd.Example = ` # Predict the final table
+premcli predict season
+
+ # Repeat a prediction exactly
+premcli predict season --seed 42
+
+ # Predict La Liga with more simulations
+premcli predict season --league laliga --simulations 50000`
+}
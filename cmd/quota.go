@@ -0,0 +1,85 @@
+/*
+Shows how much of the daily API-FOOTBALL request quota has been used.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"premcli/api"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var quotaPath = filepath.Join(cachePath, "quota.json")
+
+// Warn when fewer requests than this would be left after a command
+const lowQuotaThreshold = 10
+
+// Checks the planned number of requests against the remaining daily quota.
+// Warns when the quota is running low and refuses when it would run out,
+// unless --force is given.
+func checkQuota(planned int) error {
+	quota, ok := api.LoadQuota(quotaPath)
+	if !ok || !quota.Current() {
+		return nil
+	}
+
+	if planned > quota.Remaining {
+		if !force {
+			return fmt.Errorf("This needs up to %d API requests but only %d of today's %d remain. Use --force to run anyway.", planned, quota.Remaining, quota.Limit)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: this needs up to %d API requests but only %d of today's %d remain.\n", planned, quota.Remaining, quota.Limit)
+	} else if quota.Remaining-planned < lowQuotaThreshold {
+		fmt.Fprintf(os.Stderr, "Warning: only %d of today's %d API requests remain.\n", quota.Remaining, quota.Limit)
+	}
+
+	return nil
+}
+
+// Counts the requests made on top of a command's own. Finding the current
+// season asks /leagues, which also names leagues without an alias, and
+// loading teams to resolve a team or highlight the favourite asks /teams.
+func extraRequests(usesSeason, usesTeams bool) int {
+	planned := 0
+	if (usesSeason || usesTeams) && (season == 0 || leagueShortAlias(league) == "") {
+		planned++
+	}
+	if usesTeams {
+		planned++
+	}
+	return planned
+}
+
+var quotaCmd = &cobra.Command{
+	Use:   "quota",
+	Short: "Shows the API requests used today",
+	Long: `Shows the API requests used today.
+
+The numbers come from the rate limit headers of the last API response, so they are only as fresh as the last command that made a request.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		quota, ok := api.LoadQuota(quotaPath)
+		if !ok {
+			fmt.Println("No API requests recorded yet.")
+			return
+		}
+
+		if !quota.Current() {
+			fmt.Printf("No API requests recorded today. Your daily limit is %d requests.\n", quota.Limit)
+			return
+		}
+
+		resetsIn := time.Until(quota.ResetsAt()).Round(time.Minute)
+
+		fmt.Printf("Used:      %d of %d requests\n", quota.Used(), quota.Limit)
+		fmt.Printf("Remaining: %d\n", quota.Remaining)
+		fmt.Printf("Updated:   %s\n", quota.UpdatedAt.Local().Format("02 Jan 2006, 03:04 PM"))
+		fmt.Printf("Resets:    %s (in %s)\n", quota.ResetsAt().Local().Format("02 Jan 2006, 03:04 PM"), resetsIn)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(quotaCmd)
+}
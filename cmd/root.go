@@ -12,6 +12,9 @@ var rootCmd = &cobra.Command{
 	Long: `A Premiere League CLI for the terminal. Displays useful information to track Premiere League games right in the terminal.
 
 Requires an API-FOOTBALL api key found here: https://rapidapi.com/api-sports/api/api-football/`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return checkOutputFormat(cmd)
+	},
 }
 
 func Execute() {
@@ -22,6 +25,19 @@ func Execute() {
 	}
 }
 
+var (
+	noCache      bool
+	refreshCache bool
+	force        bool
+)
+
 func init() {
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "Don't read or write cached API responses")
+	rootCmd.PersistentFlags().BoolVar(&refreshCache, "refresh", false, "Ignore cached API responses and fetch fresh ones")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputText, "Output format: text, json, yaml or csv")
+	rootCmd.PersistentFlags().StringVarP(&leagueFlag, "league", "l", "", "League or cup by ID or alias, e.g. epl, championship, laliga, ucl")
+	rootCmd.PersistentFlags().IntVar(&season, "season", 0, "Season by the year it starts in, e.g. 2021 (default the current season)")
+	rootCmd.PersistentFlags().BoolVar(&force, "force", false, "Run even if it would use up the remaining daily API quota")
 }
@@ -0,0 +1,359 @@
+/*
+"What if" scenarios for the standings. Hypothetical results are played in the
+season's fixtures and added to the API's table, which is then ranked again,
+along with the positions each team can still finish in.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"premcli/api"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A result with a score, e.g. "WOL 2-0 TOT", and a result of a team's next
+// match, e.g. "EVE L"
+var (
+	scorePattern  = regexp.MustCompile(`^(.+?)\s+(\d+)\s*-\s*(\d+)\s+(.+)$`)
+	resultPattern = regexp.MustCompile(`^(.+?)\s+([WDLwdl])$`)
+)
+
+// Scores given to results without one
+var resultScores = map[string][2]int{
+	"W": {1, 0},
+	"D": {0, 0},
+	"L": {0, 1},
+}
+
+// A scenario file, e.g.
+//
+//	name: Wolves stay up
+//	results:
+//	  - WOL 2-0 TOT
+//	  - EVE L
+type scenarioFile struct {
+	Name    string   `yaml:"name"`
+	Results []string `yaml:"results"`
+}
+
+// A hypothetical result. Without an opponent it is the result of the team's
+// next match.
+type scenarioResult struct {
+	Text         string
+	Team         team
+	Opponent     *team
+	GoalsFor     int
+	GoalsAgainst int
+}
+
+// Highest and lowest positions a team can still finish in
+type finishRange struct {
+	Best  int
+	Worst int
+}
+
+type scenarioOutcome struct {
+	Name string
+	// The API's table with the scenario played, a table per group
+	Standings []api.Standings
+	// Ranks in the API's table, by team ID
+	Before map[int]int
+	// Each team's hypothetical results, by team ID
+	Results map[int]string
+	Ranges  map[int]finishRange
+}
+
+// Reads a scenario from a YAML file, or from results separated by commas.
+// The name is empty unless the file gives one.
+func readScenario(value string) (name string, results []string, err error) {
+	if info, err := os.Stat(value); err == nil && !info.IsDir() {
+		data, err := os.ReadFile(value)
+		if err != nil {
+			return "", nil, fmt.Errorf("Failed to read scenario: %v", err)
+		}
+
+		var file scenarioFile
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return "", nil, fmt.Errorf("Failed to parse scenario %s: %v", value, err)
+		}
+		if len(file.Results) == 0 {
+			return "", nil, fmt.Errorf("Scenario %s has no results", value)
+		}
+		return file.Name, file.Results, nil
+	}
+
+	for _, result := range strings.Split(value, ",") {
+		if result = strings.TrimSpace(result); result != "" {
+			results = append(results, result)
+		}
+	}
+	if len(results) == 0 {
+		return "", nil, fmt.Errorf("The scenario has no results")
+	}
+	return "", results, nil
+}
+
+// Parses a result such as "WOL 2-0 TOT" or "EVE L". Teams must be loaded.
+func parseScenarioResult(text string) (scenarioResult, error) {
+	text = strings.TrimSpace(text)
+	result := scenarioResult{Text: text}
+
+	if parts := scorePattern.FindStringSubmatch(text); parts != nil {
+		t, err := resolveTeam(parts[1])
+		if err != nil {
+			return result, err
+		}
+		opponent, err := resolveTeam(parts[4])
+		if err != nil {
+			return result, err
+		}
+		if t.ID == opponent.ID {
+			return result, fmt.Errorf("%q has %s playing themselves", text, t.Name)
+		}
+
+		result.Team = t
+		result.Opponent = &opponent
+		result.GoalsFor, _ = strconv.Atoi(parts[2])
+		result.GoalsAgainst, _ = strconv.Atoi(parts[3])
+		return result, nil
+	}
+
+	if parts := resultPattern.FindStringSubmatch(text); parts != nil {
+		t, err := resolveTeam(parts[1])
+		if err != nil {
+			return result, err
+		}
+
+		score := resultScores[strings.ToUpper(parts[2])]
+		result.Team = t
+		result.GoalsFor, result.GoalsAgainst = score[0], score[1]
+		return result, nil
+	}
+
+	return result, fmt.Errorf("%q isn't a result, use a score such as \"WOL 2-0 TOT\" or W, D or L such as \"EVE L\"", text)
+}
+
+// Checks if a fixture still has to be played
+func stillToPlay(match api.Match) bool {
+	return !api.IsFinished(match.Fixture.Status.Short)
+}
+
+// Plays each result in the first fixture it fits that is still to be played
+// and hasn't been given a result already. The fixtures are sorted by kickoff.
+// Returns the fixtures given a result and each team's results.
+func playScenario(matches []api.Match, results []scenarioResult) ([]api.Match, map[int]string, error) {
+	played := map[int]bool{}
+	var scenarioMatches []api.Match
+	notes := map[int]string{}
+
+	for _, result := range results {
+		index := -1
+		for i, match := range matches {
+			if played[i] || !stillToPlay(match) || !isTeamIn(match, result.Team.ID) {
+				continue
+			}
+			if result.Opponent != nil && !isTeamIn(match, result.Opponent.ID) {
+				continue
+			}
+			index = i
+			break
+		}
+		if index < 0 {
+			if result.Opponent != nil {
+				return nil, nil, fmt.Errorf("%s and %s have no fixture left for %q", result.Team.Name, result.Opponent.Name, result.Text)
+			}
+			return nil, nil, fmt.Errorf("%s have no fixture left for %q", result.Team.Name, result.Text)
+		}
+
+		match := &matches[index]
+		if match.Teams.Home.ID == result.Team.ID {
+			match.Goals.Home, match.Goals.Away = result.GoalsFor, result.GoalsAgainst
+		} else {
+			match.Goals.Home, match.Goals.Away = result.GoalsAgainst, result.GoalsFor
+		}
+		match.Fixture.Status.Short = "FT"
+		played[index] = true
+		scenarioMatches = append(scenarioMatches, *match)
+
+		for _, id := range []int{match.Teams.Home.ID, match.Teams.Away.ID} {
+			if notes[id] != "" {
+				notes[id] += ", "
+			}
+			notes[id] += formatTeamScore(*match, id)
+		}
+	}
+
+	return scenarioMatches, notes, nil
+}
+
+// Checks if a team plays in a fixture
+func isTeamIn(match api.Match, teamID int) bool {
+	return match.Teams.Home.ID == teamID || match.Teams.Away.ID == teamID
+}
+
+// Works out the highest and lowest positions each team can still finish in
+// from points alone. Teams already above a team's most possible points will
+// finish above it, and only teams that can still reach its points can finish
+// above it, so the ranges are certain though not always the narrowest.
+func finishRanges(table []api.Standing, matches []api.Match) map[int]finishRange {
+	left := map[int]int{}
+	for _, match := range matches {
+		if stillToPlay(match) {
+			left[match.Teams.Home.ID]++
+			left[match.Teams.Away.ID]++
+		}
+	}
+
+	ranges := map[int]finishRange{}
+	for _, standing := range table {
+		most := standing.Points + 3*left[standing.Team.ID]
+		r := finishRange{Best: 1, Worst: 1}
+		for _, other := range table {
+			if other.Team.ID == standing.Team.ID {
+				continue
+			}
+			if other.Points > most {
+				r.Best++
+			}
+			if other.Points+3*left[other.Team.ID] >= standing.Points {
+				r.Worst++
+			}
+		}
+		ranges[standing.Team.ID] = r
+	}
+	return ranges
+}
+
+// Formats the positions a team can finish in, e.g. "3-17", or one position
+// once it is settled
+func formatFinishRange(r finishRange) string {
+	if r.Best == r.Worst {
+		return strconv.Itoa(r.Best)
+	}
+	return fmt.Sprintf("%d-%d", r.Best, r.Worst)
+}
+
+// Works out the table after a scenario, played on top of the API's table so
+// points deductions carry over. Only fixtures in rounds of the table can be
+// given a result.
+func scenarioTable(client *api.Client, value string) (*scenarioOutcome, error) {
+	name, texts, err := readScenario(value)
+	if err != nil {
+		return nil, err
+	}
+
+	err = loadTeams(client)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []scenarioResult
+	for _, text := range texts {
+		result, err := parseScenarioResult(text)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	season := getSeason(client)
+	standings, err := client.Standings(league, season)
+	if err != nil {
+		return nil, err
+	}
+	if len(standings) == 0 {
+		return nil, fmt.Errorf("No standings found for the %d season", season)
+	}
+
+	matches, err := client.Fixtures(league, season, "", timezone)
+	if err != nil {
+		return nil, err
+	}
+	matches = tableMatches(matches)
+	sortMatches(matches)
+
+	var played []api.Match
+	for _, match := range matches {
+		if countsInTable(match.Fixture.Status.Short) {
+			played = append(played, match)
+		}
+	}
+
+	outcome := &scenarioOutcome{Name: name, Standings: standings, Before: map[int]int{}, Ranges: map[int]finishRange{}}
+
+	what := append([]api.Match(nil), matches...)
+	scenarioMatches, notes, err := playScenario(what, results)
+	if err != nil {
+		return nil, err
+	}
+	outcome.Results = notes
+
+	for _, leagueData := range standings {
+		for i, group := range leagueData.League.Standings {
+			for _, standing := range group {
+				outcome.Before[standing.Team.ID] = standing.Rank
+			}
+
+			table := applyResults(group, scenarioMatches, played)
+			leagueData.League.Standings[i] = table
+			for id, r := range finishRanges(table, groupMatches(table, what)) {
+				outcome.Ranges[id] = r
+			}
+		}
+	}
+	return outcome, nil
+}
+
+// Prints the zones of a table teams are certain to finish in or can no longer
+// reach. Being certain of a relegation zone means being relegated, and being
+// out of its reach means being safe.
+func printDecided(table []api.Standing, ranges map[int]finishRange) {
+	zones := append([]tableZone{{Label: "Title", Ranks: []int{1}}}, tableZones(table)...)
+
+	decided := false
+	for _, zone := range zones {
+		first, last := zone.Ranks[0], zone.Ranks[len(zone.Ranks)-1]
+
+		var certain, outOfReach []string
+		for _, standing := range table {
+			r := ranges[standing.Team.ID]
+			switch {
+			case r.Best >= first && r.Worst <= last:
+				certain = append(certain, standing.Team.Name)
+			case r.Worst < first || r.Best > last:
+				outOfReach = append(outOfReach, standing.Team.Name)
+			}
+		}
+
+		var parts []string
+		if strings.Contains(strings.ToLower(zone.Label), "relegation") {
+			if len(certain) > 0 {
+				parts = append(parts, "relegated "+strings.Join(certain, ", "))
+			}
+			if len(outOfReach) > 0 {
+				parts = append(parts, "safe "+strings.Join(outOfReach, ", "))
+			}
+		} else {
+			if len(certain) > 0 {
+				parts = append(parts, "clinched by "+strings.Join(certain, ", "))
+			}
+			if len(outOfReach) > 0 {
+				parts = append(parts, "out of reach for "+strings.Join(outOfReach, ", "))
+			}
+		}
+
+		if len(parts) > 0 {
+			fmt.Printf("%s: %s\n", zoneHeading(zone.Label), strings.Join(parts, "; "))
+			decided = true
+		}
+	}
+
+	if !decided {
+		fmt.Println("No positions are decided yet.")
+	}
+}
@@ -0,0 +1,58 @@
+/*
+Tests the positions teams can still finish in.
+*/
+package cmd
+
+import (
+	"premcli/api"
+	"testing"
+)
+
+func TestFinishRanges(t *testing.T) {
+	table := make([]api.Standing, 3)
+	for i, points := range []int{10, 8, 1} {
+		table[i].Rank = i + 1
+		table[i].Team.ID = i + 1
+		table[i].Points = points
+	}
+
+	// Alpha and Beta play each other and Beta still plays Gamma. Played and
+	// cancelled fixtures don't count.
+	matches := []api.Match{
+		testFixture(1, 2, "NS", 0, 0),
+		testFixture(2, 3, "NS", 0, 0),
+		testFixture(1, 3, "FT", 1, 0),
+		testFixture(3, 1, "CANC", 0, 0),
+	}
+
+	ranges := finishRanges(table, matches)
+	want := map[int]finishRange{
+		1: {Best: 1, Worst: 2},
+		2: {Best: 1, Worst: 2},
+		// Gamma can reach 4 points at most
+		3: {Best: 3, Worst: 3},
+	}
+	for id, r := range want {
+		if ranges[id] != r {
+			t.Errorf("%s can finish %s, want %s", testTeamNames[id], formatFinishRange(ranges[id]), formatFinishRange(r))
+		}
+	}
+}
+
+func TestFinishRangesLevelTeams(t *testing.T) {
+	// With nothing left to play, teams level on points could still finish
+	// either way round as far as points tell
+	table := make([]api.Standing, 2)
+	for i := range table {
+		table[i].Rank = i + 1
+		table[i].Team.ID = i + 1
+		table[i].Points = 5
+	}
+
+	ranges := finishRanges(table, nil)
+	for id := 1; id <= 2; id++ {
+		if r := ranges[id]; r.Best != 1 || r.Worst != 2 {
+			t.Errorf("%s can finish %s, want 1-2", testTeamNames[id], formatFinishRange(r))
+		}
+	}
+}
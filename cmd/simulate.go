@@ -0,0 +1,256 @@
+/*
+Simulates the rest of a season. Each team's attack and defence are fitted to
+the season's results and the remaining fixtures are played out many times, with
+goals drawn from a Poisson distribution, to see where teams tend to finish.
+*/
+package cmd
+
+import (
+	"math"
+	"math/rand"
+	"premcli/api"
+	"runtime"
+	"sync"
+)
+
+// Average matches added to each team's record when fitting, pulling teams
+// that have played little towards the league average
+const modelPriorMatches = 5
+
+// Average goals used before any match has been played
+const (
+	defaultHomeGoals = 1.5
+	defaultAwayGoals = 1.2
+)
+
+// Team strengths relative to the league average. An attack of 1.2 scores 20%
+// more than average and a defence of 0.8 concedes 20% less.
+type poissonModel struct {
+	// Average goals per match scored by the home and away teams
+	homeGoals float64
+	awayGoals float64
+	attack    map[int]float64
+	defence   map[int]float64
+}
+
+// Fits the model to the finished fixtures among matches. Each team's goals
+// are compared with what an average team would have scored and conceded in
+// the same home and away matches.
+func fitPoissonModel(matches []api.Match) poissonModel {
+	model := poissonModel{
+		homeGoals: defaultHomeGoals,
+		awayGoals: defaultAwayGoals,
+		attack:    map[int]float64{},
+		defence:   map[int]float64{},
+	}
+
+	var played []api.Match
+	homeTotal, awayTotal := 0, 0
+	for _, match := range matches {
+		if !countsInTable(match.Fixture.Status.Short) {
+			continue
+		}
+		played = append(played, match)
+		homeTotal += match.Goals.Home
+		awayTotal += match.Goals.Away
+	}
+	if len(played) > 0 {
+		model.homeGoals = float64(homeTotal) / float64(len(played))
+		model.awayGoals = float64(awayTotal) / float64(len(played))
+	}
+
+	scored, conceded := map[int]float64{}, map[int]float64{}
+	expectedScored, expectedConceded := map[int]float64{}, map[int]float64{}
+	for _, match := range played {
+		home, away := match.Teams.Home.ID, match.Teams.Away.ID
+		scored[home] += float64(match.Goals.Home)
+		conceded[home] += float64(match.Goals.Away)
+		scored[away] += float64(match.Goals.Away)
+		conceded[away] += float64(match.Goals.Home)
+		expectedScored[home] += model.homeGoals
+		expectedConceded[home] += model.awayGoals
+		expectedScored[away] += model.awayGoals
+		expectedConceded[away] += model.homeGoals
+	}
+
+	prior := modelPriorMatches * (model.homeGoals + model.awayGoals) / 2
+	for id := range expectedScored {
+		model.attack[id] = (scored[id] + prior) / (expectedScored[id] + prior)
+		model.defence[id] = (conceded[id] + prior) / (expectedConceded[id] + prior)
+	}
+	return model
+}
+
+// Gets a team's attack or defence, average for teams without a result
+func strength(strengths map[int]float64, teamID int) float64 {
+	if value, exists := strengths[teamID]; exists {
+		return value
+	}
+	return 1
+}
+
+// Gets the goals each team is expected to score in a match
+func (m poissonModel) expectedGoals(match api.Match) (home, away float64) {
+	homeID, awayID := match.Teams.Home.ID, match.Teams.Away.ID
+	home = m.homeGoals * strength(m.attack, homeID) * strength(m.defence, awayID)
+	away = m.awayGoals * strength(m.attack, awayID) * strength(m.defence, homeID)
+	return home, away
+}
+
+// Draws a number of goals from a Poisson distribution with the given mean
+func poissonGoals(rng *rand.Rand, mean float64) int {
+	limit := math.Exp(-mean)
+	goals, p := 0, rng.Float64()
+	for p > limit {
+		goals++
+		p *= rng.Float64()
+	}
+	return goals
+}
+
+// Plays out a match. Matches in progress keep their score and only play the
+// minutes left.
+func (m poissonModel) play(rng *rand.Rand, match api.Match) api.Match {
+	home, away := m.expectedGoals(match)
+	if api.IsLive(match.Fixture.Status.Short) {
+		left := math.Max(0, float64(90-match.Fixture.Status.Elapsed)) / 90
+		match.Goals.Home += poissonGoals(rng, home*left)
+		match.Goals.Away += poissonGoals(rng, away*left)
+	} else {
+		match.Goals.Home = poissonGoals(rng, home)
+		match.Goals.Away = poissonGoals(rng, away)
+	}
+	match.Fixture.Status.Short = "FT"
+	return match
+}
+
+// How often each team finished in each position over every simulation
+type seasonPrediction struct {
+	Simulations int
+	// The table as it stands, ordered by rank
+	Table []api.Standing
+	// Number of simulations each team finished in each position, by team ID.
+	// Index 0 is first place.
+	Finishes map[int][]int
+	// Points each team finished on, added up over every simulation
+	TotalPoints map[int]int
+}
+
+// Gets a team's average points at the end of the season
+func (p seasonPrediction) expectedPoints(teamID int) float64 {
+	return float64(p.TotalPoints[teamID]) / float64(p.Simulations)
+}
+
+// Gets the number of simulations a team finished in any of the given ranks
+func (p seasonPrediction) finishesIn(teamID int, ranks []int) int {
+	count := 0
+	for _, rank := range ranks {
+		if rank >= 1 && rank <= len(p.Finishes[teamID]) {
+			count += p.Finishes[teamID][rank-1]
+		}
+	}
+	return count
+}
+
+// Plays out the fixtures still to come the given number of times, spread over
+// a goroutine per CPU. Every simulation draws from its own generator seeded
+// from seed and its number, so a seed gives the same prediction however the
+// simulations are shared out. current is the API's table, nil if it isn't
+// known. Points its rows have beyond their results, such as deductions, are
+// kept in every simulation.
+func simulateSeason(matches []api.Match, current []api.Standing, simulations int, seed int64) seasonPrediction {
+	model := fitPoissonModel(matches)
+	rules := leagueTiebreakRules()
+
+	table := buildTable(matches)
+	adjustments := map[int]int{}
+	if len(current) > 0 {
+		table = append([]api.Standing(nil), current...)
+		for _, standing := range current {
+			adjustments[standing.Team.ID] = standing.Points - standing.All.Points()
+		}
+	}
+
+	// Finished fixtures first, then the rest, so tallying needs no sorting
+	var finished, remaining []api.Match
+	for _, match := range matches {
+		switch {
+		case countsInTable(match.Fixture.Status.Short):
+			finished = append(finished, match)
+		case !api.IsFinished(match.Fixture.Status.Short):
+			remaining = append(remaining, match)
+		}
+	}
+	sortMatches(finished)
+	sortMatches(remaining)
+
+	prediction := seasonPrediction{
+		Simulations: simulations,
+		Table:       table,
+		Finishes:    map[int][]int{},
+		TotalPoints: map[int]int{},
+	}
+	places := len(prediction.Table)
+	for _, standing := range prediction.Table {
+		prediction.Finishes[standing.Team.ID] = make([]int, places)
+	}
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		jobs = make(chan int)
+	)
+	for worker := 0; worker < runtime.NumCPU(); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			finishes := map[int][]int{}
+			points := map[int]int{}
+			season := make([]api.Match, len(finished)+len(remaining))
+			copy(season, finished)
+
+			for simulation := range jobs {
+				rng := rand.New(rand.NewSource(seed + int64(simulation)))
+				for i, match := range remaining {
+					season[len(finished)+i] = model.play(rng, match)
+				}
+
+				table := tallyTable(season)
+				for i := range table {
+					table[i].Points += adjustments[table[i].Team.ID]
+				}
+				rankTable(table, season, rules)
+				for _, standing := range table {
+					if finishes[standing.Team.ID] == nil {
+						finishes[standing.Team.ID] = make([]int, places)
+					}
+					if standing.Rank <= places {
+						finishes[standing.Team.ID][standing.Rank-1]++
+					}
+					points[standing.Team.ID] += standing.Points
+				}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for id, counts := range finishes {
+				if prediction.Finishes[id] == nil {
+					continue
+				}
+				for rank, count := range counts {
+					prediction.Finishes[id][rank] += count
+				}
+				prediction.TotalPoints[id] += points[id]
+			}
+		}()
+	}
+
+	for simulation := 0; simulation < simulations; simulation++ {
+		jobs <- simulation
+	}
+	close(jobs)
+	wg.Wait()
+
+	return prediction
+}
@@ -0,0 +1,59 @@
+/*
+Tests that season predictions can be repeated from a seed and keep the points
+deductions in the API's table.
+*/
+package cmd
+
+import (
+	"premcli/api"
+	"reflect"
+	"testing"
+)
+
+// Alpha and Beta have played once, with every other match still to come
+var predictionFixtures = []api.Match{
+	testFixture(1, 2, "FT", 2, 1),
+	testFixture(3, 4, "FT", 0, 0),
+	testFixture(1, 3, "NS", 0, 0),
+	testFixture(2, 4, "NS", 0, 0),
+	testFixture(4, 1, "NS", 0, 0),
+	testFixture(3, 2, "NS", 0, 0),
+}
+
+func TestSimulateSeasonSeed(t *testing.T) {
+	useTiebreakers(t, premierLeague, "")
+
+	first := simulateSeason(predictionFixtures, nil, 200, 7)
+	second := simulateSeason(predictionFixtures, nil, 200, 7)
+	if !reflect.DeepEqual(first.Finishes, second.Finishes) || !reflect.DeepEqual(first.TotalPoints, second.TotalPoints) {
+		t.Errorf("the same seed gave different predictions")
+	}
+}
+
+func TestSimulateSeasonKeepsDeductions(t *testing.T) {
+	useTiebreakers(t, premierLeague, "")
+
+	// Beta has had 6 points deducted from a record worth 0
+	current := buildTable(predictionFixtures)
+	for i := range current {
+		if current[i].Team.ID == 2 {
+			current[i].Points -= 6
+		}
+	}
+
+	const simulations = 200
+	without := simulateSeason(predictionFixtures, nil, simulations, 7)
+	with := simulateSeason(predictionFixtures, current, simulations, 7)
+
+	if got, want := with.TotalPoints[2], without.TotalPoints[2]-6*simulations; got != want {
+		t.Errorf("Beta got %d points over every simulation, want %d", got, want)
+	}
+	if got, want := with.TotalPoints[1], without.TotalPoints[1]; got != want {
+		t.Errorf("Alpha got %d points over every simulation, want %d", got, want)
+	}
+	for _, standing := range with.Table {
+		if standing.Team.ID == 2 && standing.Points != -6 {
+			t.Errorf("Beta is shown on %d points, want -6", standing.Points)
+		}
+	}
+}
@@ -1,139 +1,633 @@
 /*
-Displays the live standings in the terminal.
+Displays the live standings in the terminal. Cup competitions and split
+leagues are printed as one table per group.
 */
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
-	"text/tabwriter"
+	"premcli/api"
+	"regexp"
+	"sort"
+	"strings"
 
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
-type ApiResponseStandings struct {
-	Response []Standings `json:"response"`
-}
-
-type Standings struct {
-	League struct {
-		Standings [][]struct {
-			Rank int
-			Team struct {
-				Name string
-			}
-			Points    int
-			GoalsDiff int
-			Form      string
-			All       struct {
-				Played int
-				Win    int
-				Draw   int
-				Lose   int
-				Goals  struct {
-					For     int
-					Against int
-				}
-			}
+var (
+	homeOnly     bool
+	awayOnly     bool
+	compare      bool
+	historyRound string
+	showChart    bool
+	chartTeams   []string
+	liveView     bool
+	scenario     string
+)
+
+// Teams charted when none are chosen and there's no favourite team
+const defaultChartTeams = 5
+
+// Matches empty details the API leaves on some zones, e.g. "(League phase: )"
+var emptyZoneDetails = regexp.MustCompile(`\s*\([^()]*:\s*\)$`)
+
+// Gets the zone label of a standing, e.g. "Promotion - Champions League"
+func zoneLabel(description string) string {
+	return emptyZoneDetails.ReplaceAllString(strings.TrimSpace(description), "")
+}
+
+// Gets the title of a group's table, falling back to the league name
+func groupTitle(leagueData api.Standings, group []api.Standing) string {
+	if len(group) > 0 && group[0].Group != "" {
+		return group[0].Group
+	}
+	return leagueData.League.Name
+}
+
+// Gets the colour of a qualification or relegation zone, nil outside of one
+func zoneColor(description string) *color.Color {
+	zone := strings.ToLower(description)
+	switch {
+	case zone == "":
+		return nil
+	case strings.Contains(zone, "relegation"):
+		return color.New(color.FgRed)
+	case strings.Contains(zone, "conference"):
+		return color.New(color.FgCyan)
+	case strings.Contains(zone, "europa"):
+		return color.New(color.FgBlue)
+	case strings.Contains(zone, "play"):
+		return color.New(color.FgYellow)
+	case strings.Contains(zone, "champions league"), strings.Contains(zone, "promotion"):
+		return color.New(color.FgGreen)
+	}
+	return nil
+}
+
+// Colours for the results in the form guide
+var formColors = map[rune]*color.Color{
+	'W': color.New(color.FgBlack, color.BgGreen),
+	'D': color.New(color.FgBlack, color.BgWhite),
+	'L': color.New(color.FgWhite, color.BgRed),
+}
+
+// Shows the form guide as a block per result, e.g. "WDLWW"
+func formatForm(form string) string {
+	var b strings.Builder
+	for _, result := range form {
+		if c, ok := formColors[result]; ok {
+			b.WriteString(c.Sprint(string(result)))
+			continue
 		}
+		b.WriteRune(result)
 	}
+	return b.String()
 }
 
-// Build Standings URL for the API
-func buildStandingsURL() string {
-	baseURL := "https://api-football-v1.p.rapidapi.com/v3/standings?league=39"
+// Builds the table of only the home or only the away results, ranked by
+// points, goal difference and goals scored. Form and zones only apply to the
+// full table so they are dropped.
+func splitTable(group []api.Standing, home bool) []api.Standing {
+	split := make([]api.Standing, len(group))
+	for i, standing := range group {
+		record := standing.Away
+		if home {
+			record = standing.Home
+		}
+		standing.All = record
+		standing.Points = record.Points()
+		standing.GoalsDiff = record.GoalDifference()
+		standing.Form = ""
+		standing.Description = ""
+		split[i] = standing
+	}
 
-	season := "&season=" + getSeasonYear()
+	sort.SliceStable(split, func(i, j int) bool {
+		a, b := split[i], split[j]
+		if a.Points != b.Points {
+			return a.Points > b.Points
+		}
+		if a.GoalsDiff != b.GoalsDiff {
+			return a.GoalsDiff > b.GoalsDiff
+		}
+		return a.All.Goals.For > b.All.Goals.For
+	})
 
-	return baseURL + season
+	for i := range split {
+		split[i].Rank = i + 1
+	}
+	return split
+}
 
+// Prints a group title underlined
+func printTableTitle(title string) {
+	color.Set(color.Underline)
+	fmt.Println(title)
+	color.Unset()
 }
 
-// Gets the standings
-func getStandings() ([]Standings, error) {
-	url := buildStandingsURL()
+// Gets the function that colours the cells of a row, in the zone's colour or
+// highlighted for the favourite team
+func rowPainter(standing api.Standing, zone string) func(a ...interface{}) string {
+	return teamPainter(standing.Team.ID, zone)
+}
 
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("Error creating request: %v", err)
+// Gets the function that colours the cells of a team's row
+func teamPainter(teamID int, zone string) func(a ...interface{}) string {
+	rowColor := zoneColor(zone)
+	if isFavTeam(teamID, favTeam) {
+		rowColor = color.New(color.FgMagenta, color.Bold)
+	}
+	if rowColor == nil {
+		return fmt.Sprint
+	}
+	return rowColor.Sprint
+}
+
+// Prints a group's table under its title. Rows in a qualification or
+// relegation zone take its colour and the favourite team is highlighted. The
+// zone is named on the first row of each run of teams that share it. Tables
+// without form or zones, such as home and away tables, leave those columns out.
+func printStandingsTable(title string, group []api.Standing, showZones bool) {
+	printTableTitle(title)
+
+	header := []string{"Rank", "Club", "MP", "W", "D", "L", "GF", "GA", "GD", "Pts"}
+	if showZones {
+		header = append(header, "Form", "Zone")
+	}
+	t := newTable(header...)
+
+	previousZone := ""
+	for _, standing := range group {
+		zone := zoneLabel(standing.Description)
+		marker := ""
+		if zone != previousZone {
+			marker = zone
+		}
+		previousZone = zone
+
+		paint := rowPainter(standing, zone)
+
+		row := []string{
+			paint(standing.Rank),
+			paint(standing.Team.Name),
+			paint(standing.All.Played),
+			paint(standing.All.Win),
+			paint(standing.All.Draw),
+			paint(standing.All.Lose),
+			paint(standing.All.Goals.For),
+			paint(standing.All.Goals.Against),
+			paint(standing.GoalsDiff),
+			paint(standing.Points),
+		}
+		if showZones {
+			row = append(row, formatForm(standing.Form), paint(marker))
+		}
+		t.AddRow(row...)
 	}
 
-	req.Header.Add("X-RapidAPI-Key", apiKey)
-	req.Header.Add("X-RapidAPI-Host", "api-football-v1.p.rapidapi.com")
+	t.Render(os.Stdout)
+}
+
+// Formats a record as wins, draws and losses, e.g. "5-2-1"
+func formatRecord(record api.Record) string {
+	return fmt.Sprintf("%d-%d-%d", record.Win, record.Draw, record.Lose)
+}
+
+// Formats the goals of a record, e.g. "14:6"
+func formatRecordGoals(record api.Record) string {
+	return fmt.Sprintf("%d:%d", record.Goals.For, record.Goals.Against)
+}
 
-	res, err := http.DefaultClient.Do(req)
+// Prints each team's home and away records side by side in the order of the
+// full table
+func printCompareTable(title string, group []api.Standing) {
+	printTableTitle(title)
+
+	t := newTable("Rank", "Club", "Home MP", "W-D-L", "Goals", "Pts", "Away MP", "W-D-L", "Goals", "Pts")
+	for _, standing := range group {
+		paint := rowPainter(standing, "")
+
+		t.AddRow(
+			paint(standing.Rank),
+			paint(standing.Team.Name),
+			paint(standing.Home.Played),
+			paint(formatRecord(standing.Home)),
+			paint(formatRecordGoals(standing.Home)),
+			paint(standing.Home.Points()),
+			paint(standing.Away.Played),
+			paint(formatRecord(standing.Away)),
+			paint(formatRecordGoals(standing.Away)),
+			paint(standing.Away.Points()),
+		)
+	}
+
+	t.Render(os.Stdout)
+}
+
+// Formats a match's score from a team's point of view, e.g. "2-1 v Arsenal"
+// at home or "2-1 @ Arsenal" away
+func formatTeamScore(match api.Match, teamID int) string {
+	if match.Teams.Home.ID == teamID {
+		return fmt.Sprintf("%d-%d v %s", match.Goals.Home, match.Goals.Away, match.Teams.Away.Name)
+	}
+	return fmt.Sprintf("%d-%d @ %s", match.Goals.Away, match.Goals.Home, match.Teams.Home.Name)
+}
+
+// Formats a team's match in progress, e.g. "2-1 v Arsenal 67'"
+func formatLiveMatch(match api.Match, teamID int) string {
+	return fmt.Sprintf("%s %d'", formatTeamScore(match, teamID), match.Fixture.Status.Elapsed)
+}
+
+// Formats a move up or down the table, e.g. "▲2"
+func formatMove(before, after int) string {
+	switch {
+	case before == 0 || before == after:
+		return "-"
+	case after < before:
+		return fmt.Sprintf("▲%d", before-after)
+	}
+	return fmt.Sprintf("▼%d", after-before)
+}
+
+// Works out the API's table with every match in progress counted at its
+// current score, on top of each group's rows. Only matches in rounds of the
+// table count. Also returns each team's rank in the API's table and the match
+// each team is playing.
+func liveTable(client *api.Client) (standings []api.Standings, before map[int]int, live map[int]api.Match, err error) {
+	season := getSeason(client)
+	standings, err = client.Standings(league, season)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(standings) == 0 {
+		return nil, nil, nil, fmt.Errorf("No standings found for the %d season", season)
+	}
+
+	matches, err := client.Fixtures(league, season, "", timezone)
 	if err != nil {
-		return nil, fmt.Errorf("Error executing request: %v", err)
+		return nil, nil, nil, err
+	}
+
+	var played, playing []api.Match
+	live = map[int]api.Match{}
+	for _, match := range tableMatches(matches) {
+		switch {
+		case countsInTable(match.Fixture.Status.Short):
+			played = append(played, match)
+		case api.IsLive(match.Fixture.Status.Short):
+			playing = append(playing, match)
+			live[match.Teams.Home.ID] = match
+			live[match.Teams.Away.ID] = match
+		}
+	}
+	playing = withLiveScores(playing)
+
+	before = map[int]int{}
+	for _, leagueData := range standings {
+		for i, group := range leagueData.League.Standings {
+			for _, standing := range group {
+				before[standing.Team.ID] = standing.Rank
+			}
+			leagueData.League.Standings[i] = applyResults(group, playing, played)
+		}
+	}
+
+	return standings, before, live, nil
+}
+
+// Gives each row of a table the zone of its rank
+func addZones(table []api.Standing, zones map[int]string) {
+	for i := range table {
+		table[i].Description = zones[table[i].Rank]
+	}
+}
+
+// Prints a worked out table with each team's move against another table and
+// extra columns at the end, filled in for some teams
+func printMovesTable(title string, table []api.Standing, before map[int]int, columns []string, cells map[int][]string) {
+	printTableTitle(title)
+
+	header := append([]string{"Rank", "Move", "Club", "MP", "W", "D", "L", "GF", "GA", "GD", "Pts"}, columns...)
+	t := newTable(header...)
+	for _, standing := range table {
+		paint := rowPainter(standing, zoneLabel(standing.Description))
+
+		row := []string{
+			paint(standing.Rank),
+			paint(formatMove(before[standing.Team.ID], standing.Rank)),
+			paint(standing.Team.Name),
+			paint(standing.All.Played),
+			paint(standing.All.Win),
+			paint(standing.All.Draw),
+			paint(standing.All.Lose),
+			paint(standing.All.Goals.For),
+			paint(standing.All.Goals.Against),
+			paint(standing.GoalsDiff),
+			paint(standing.Points),
+		}
+		extra := cells[standing.Team.ID]
+		for i := range columns {
+			cell := ""
+			if i < len(extra) {
+				cell = extra[i]
+			}
+			row = append(row, paint(cell))
+		}
+		t.AddRow(row...)
+	}
+
+	t.Render(os.Stdout)
+}
+
+// Prints the live table with each team's move against the table without the
+// matches in progress and the score of any match they are playing
+func printLiveTable(title string, table []api.Standing, before map[int]int, live map[int]api.Match) {
+	cells := map[int][]string{}
+	for id, match := range live {
+		cells[id] = []string{formatLiveMatch(match, id)}
 	}
+	printMovesTable(title, table, before, []string{"Live"}, cells)
+}
 
-	defer res.Body.Close()
-	body, err := io.ReadAll(res.Body)
+// Draws the chosen teams' positions after every round. Without --team the
+// favourite team is drawn, or the top of the table if there isn't one.
+func drawPositionChart(client *api.Client) {
+	history, err := loadHistory(client)
 	if err != nil {
-		return nil, fmt.Errorf("Error reading response: %v", err)
+		exitWithError("Error fetching and parsing:", err)
+	}
+
+	last := history.lastPlayedRound()
+	if last == 0 {
+		exitWithError("Error drawing chart:", fmt.Errorf("No rounds have been played yet this season"))
 	}
 
-	var responseData ApiResponseStandings
-	err = json.Unmarshal(body, &responseData)
+	err = loadTeams(client)
 	if err != nil {
-		return nil, fmt.Errorf("Error parsing JSON response: %v", err)
+		exitWithError("Error loading teams:", err)
+	}
+
+	queries := chartTeams
+	if len(queries) == 0 && favTeam != "" {
+		queries = []string{favTeam}
 	}
 
-	return responseData.Response, nil
+	var selected []team
+	for _, query := range queries {
+		t, err := resolveTeam(query)
+		if err != nil {
+			exitWithError("Invalid team:", err)
+		}
+		selected = append(selected, t)
+	}
+
+	// Groups share the chart, so it is as tall as the biggest one
+	table := history.table(last)
+	places := 0
+	for _, standing := range table {
+		places = max(places, standing.Rank)
+	}
+	if len(selected) == 0 {
+		for _, standing := range table[:min(defaultChartTeams, len(table))] {
+			selected = append(selected, team{ID: standing.Team.ID, Name: standing.Team.Name})
+		}
+	}
+	if len(selected) > len(chartMarkers) {
+		exitWithError("Invalid flags:", fmt.Errorf("Chart up to %d teams at a time", len(chartMarkers)))
+	}
+
+	printTableTitle(fmt.Sprintf("%s positions by round", leagueName(client)))
+	fmt.Print(renderPositionChart(history.positions(selected), last, places))
+
+	if err := history.save(); err != nil {
+		fmt.Fprintln(os.Stderr, "Warning: couldn't save the store:", err)
+	}
 }
 
 // standingsCmd represents the standings command
 var standingsCmd = &cobra.Command{
-	Use:   "standings",
-	Short: "Displays the current standings",
-	Long:  `Displays the current standings for the premier league.`,
+	Use:         "standings",
+	Short:       "Displays the current standings",
+	Long:        `Displays the current standings for the followed league, the Premier League unless --league or LEAGUE in the config says otherwise.`,
+	Annotations: map[string]string{machineOutputAnnotation: ""},
 
 	Run: func(cmd *cobra.Command, args []string) {
 		// Get the config
 		err := GetConfig()
 		if err != nil {
-			fmt.Println("Error loading config:", err)
-			return
+			exitWithError("Error loading config:", err)
 		}
 
-		// Get the standings
-		standings, err := getStandings()
+		if (homeOnly && awayOnly) || (compare && (homeOnly || awayOnly)) {
+			exitWithError("Invalid flags:", fmt.Errorf("Use only one of --home, --away and --compare"))
+		}
+		if showChart && (historyRound != "" || homeOnly || awayOnly || compare) {
+			exitWithError("Invalid flags:", fmt.Errorf("--chart can't be combined with other views"))
+		}
+		if liveView && (showChart || historyRound != "" || homeOnly || awayOnly || compare) {
+			exitWithError("Invalid flags:", fmt.Errorf("--live can't be combined with other views"))
+		}
+		if scenario != "" && (liveView || showChart || historyRound != "" || homeOnly || awayOnly || compare) {
+			exitWithError("Invalid flags:", fmt.Errorf("--scenario can't be combined with other views"))
+		}
+		if len(chartTeams) > 0 && !showChart {
+			exitWithError("Invalid flags:", fmt.Errorf("--team only works with --chart"))
+		}
+		if showChart && machineOutput() {
+			exitWithError("Invalid flags:", fmt.Errorf("--chart can't be combined with --output"))
+		}
+
+		// The current table is recorded as a snapshot, which needs the rounds.
+		// Live and scenario tables need the season's fixtures instead. Charts
+		// and past tables need the rounds, the season's fixtures and the
+		// current table. Charts and scenarios always load the teams.
+		planned := 2
+		if showChart || historyRound != "" {
+			planned = 3
+		}
+		usesTeams := showChart || scenario != "" || (favTeam != "" && !machineOutput())
+		err = checkQuota(planned + extraRequests(true, usesTeams))
 		if err != nil {
-			fmt.Println("Error fetching and parsing:", err)
+			exitWithError("Not enough API quota:", err)
+		}
+
+		client := newClient()
+
+		if showChart {
+			drawPositionChart(client)
 			return
 		}
 
-		// Initialise Tabswriter
-		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.Debug|tabwriter.AlignRight)
-		fmt.Fprintln(writer, "Rank\tClub\tMP\tW\tD\tL\tGF\tGA\tGD\tPts\tForm\t")
+		if liveView {
+			standings, before, live, err := liveTable(client)
+			if err != nil {
+				exitWithError("Error fetching and parsing:", err)
+			}
 
-		// Iterate through the standings and print the desired information
-		for _, leagueData := range standings {
-			for _, standingsRow := range leagueData.League.Standings {
-				for _, standing := range standingsRow {
-					fmt.Fprintf(writer, "%d\t%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%s\t\n",
-						standing.Rank,
-						standing.Team.Name,
-						standing.All.Played,
-						standing.All.Win,
-						standing.All.Draw,
-						standing.All.Lose,
-						standing.All.Goals.For,
-						standing.All.Goals.Against,
-						standing.GoalsDiff,
-						standing.Points,
-						standing.Form,
-					)
+			if machineOutput() {
+				err = writeStandings(standings)
+				if err != nil {
+					exitWithError("Error writing output:", err)
 				}
+				return
 			}
+
+			loadFavTeam(client)
+			for _, leagueData := range standings {
+				for i, group := range leagueData.League.Standings {
+					if i > 0 {
+						fmt.Println()
+					}
+
+					title := groupTitle(leagueData, group) + " - live"
+					if len(live) == 0 {
+						title += ", no matches in progress"
+					}
+					printLiveTable(title, group, before, live)
+				}
+			}
+			return
 		}
 
-		writer.Flush()
+		if scenario != "" {
+			outcome, err := scenarioTable(client, scenario)
+			if err != nil {
+				exitWithError("Invalid scenario:", err)
+			}
+
+			if machineOutput() {
+				err = writeStandings(outcome.Standings)
+				if err != nil {
+					exitWithError("Error writing output:", err)
+				}
+				return
+			}
+
+			loadFavTeam(client)
+			for _, leagueData := range outcome.Standings {
+				for i, group := range leagueData.League.Standings {
+					if i > 0 {
+						fmt.Println()
+					}
+
+					title := groupTitle(leagueData, group) + " - what if"
+					if outcome.Name != "" {
+						title = groupTitle(leagueData, group) + " - " + outcome.Name
+					}
+
+					cells := map[int][]string{}
+					for _, standing := range group {
+						cells[standing.Team.ID] = []string{outcome.Results[standing.Team.ID], formatFinishRange(outcome.Ranges[standing.Team.ID])}
+					}
+					printMovesTable(title, group, outcome.Before, []string{"Result", "Can finish"}, cells)
+					fmt.Println()
+					printDecided(group, outcome.Ranges)
+				}
+			}
+			return
+		}
+
+		var standings []api.Standings
+		if historyRound != "" {
+			history, err := loadHistory(client)
+			if err != nil {
+				exitWithError("Error fetching and parsing:", err)
+			}
+
+			standings, err = history.standingsAfter(historyRound, leagueName(client))
+			if err != nil {
+				exitWithError("Error getting round:", err)
+			}
+
+			if err := history.save(); err != nil {
+				fmt.Fprintln(os.Stderr, "Warning: couldn't save the store:", err)
+			}
+		} else {
+			// Get the standings
+			standings, err = client.Standings(league, getSeason(client))
+			if err != nil {
+				exitWithError("Error fetching and parsing:", err)
+			}
+
+			if err := recordSnapshot(client, standings, getSeason(client)); err != nil {
+				fmt.Fprintln(os.Stderr, "Warning: couldn't record a snapshot:", err)
+			}
+		}
+
+		if homeOnly || awayOnly {
+			for _, leagueData := range standings {
+				for i, group := range leagueData.League.Standings {
+					leagueData.League.Standings[i] = splitTable(group, homeOnly)
+				}
+			}
+		}
+
+		if machineOutput() {
+			err = writeStandings(standings)
+			if err != nil {
+				exitWithError("Error writing output:", err)
+			}
+			return
+		}
+
+		loadFavTeam(client)
+
+		// Cup competitions and split leagues have a table per group
+		for _, leagueData := range standings {
+			for i, group := range leagueData.League.Standings {
+				if i > 0 {
+					fmt.Println()
+				}
+
+				title := groupTitle(leagueData, group)
+				switch {
+				case compare:
+					printCompareTable(title+" - home and away", group)
+				case homeOnly:
+					printStandingsTable(title+" - home", group, false)
+				case awayOnly:
+					printStandingsTable(title+" - away", group, false)
+				default:
+					printStandingsTable(title, group, true)
+				}
+			}
+		}
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(standingsCmd)
+
+	standingsCmd.Flags().BoolVar(&homeOnly, "home", false, "Rank teams by their home results only")
+	standingsCmd.Flags().BoolVar(&awayOnly, "away", false, "Rank teams by their away results only")
+	standingsCmd.Flags().BoolVar(&compare, "compare", false, "Show home and away records side by side")
+	standingsCmd.Flags().StringVarP(&historyRound, "round", "r", "", "Show the table as it was after a round, by number or name")
+	standingsCmd.Flags().BoolVar(&showChart, "chart", false, "Chart league positions by round")
+	standingsCmd.Flags().BoolVar(&liveView, "live", false, "Work out the table with matches in progress at their current score")
+	standingsCmd.Flags().StringVar(&scenario, "scenario", "", "Show the table after hypothetical results, e.g. 'WOL 2-0 TOT, EVE L', or a YAML scenario file")
+	standingsCmd.Flags().StringSliceVarP(&chartTeams, "team", "t", nil, "Teams to chart, e.g. WOL,Spurs (default your favourite team)")
+
+	standingsCmd.Example = ` # Show the current table
+premcli standings
+
+ # Rank teams by their home results
+premcli standings --home
+
+ # Compare home and away records
+premcli standings --compare
+
+ # Show the table after round 10
+premcli standings --round 10
+
+ # Chart the positions of two teams over the season
+premcli standings --chart --team WOL,Spurs
+
+ # Show the table as it stands with the scores of matches being played
+premcli standings --live
+
+ # See what happens if Wolves beat Spurs 2-0 and Everton lose
+premcli standings --scenario 'WOL 2-0 TOT, EVE L'`
 }
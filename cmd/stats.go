@@ -0,0 +1,158 @@
+/*
+Displays the match statistics of a fixture given the fixtureID as a two-sided
+bar chart comparing both teams.
+*/
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"premcli/api"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// A statistic shown in the chart, Type being the name used by the API
+type statRow struct {
+	Label string
+	Type  string
+}
+
+var statRows = []statRow{
+	{"Possession", "Ball Possession"},
+	{"Expected goals", "expected_goals"},
+	{"Total shots", "Total Shots"},
+	{"Shots on target", "Shots on Goal"},
+	{"Shots off target", "Shots off Goal"},
+	{"Blocked shots", "Blocked Shots"},
+	{"Corners", "Corner Kicks"},
+	{"Fouls", "Fouls"},
+	{"Yellow cards", "Yellow Cards"},
+	{"Red cards", "Red Cards"},
+	{"Passes", "Total passes"},
+	{"Pass accuracy", "Passes %"},
+}
+
+// The statistics shown in the live watch header
+var liveStatRows = []statRow{
+	{"Possession", "Ball Possession"},
+	{"Expected goals", "expected_goals"},
+	{"Shots on target", "Shots on Goal"},
+	{"Total shots", "Total Shots"},
+	{"Corners", "Corner Kicks"},
+}
+
+// Width of each side of the bar chart
+const statBarWidth = 20
+
+// Formats a statistic value the way the API shows it
+func formatStatValue(statistic api.Statistic) string {
+	switch value := statistic.Value.(type) {
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case string:
+		return value
+	}
+	return "0"
+}
+
+// Draws a bar of the given length, in magenta for the favourite team
+func statBar(length int, highlight bool) string {
+	bar := strings.Repeat("█", length)
+	if highlight {
+		return color.MagentaString(bar)
+	}
+	return bar
+}
+
+// Formats the statistics of both teams as a two-sided bar chart. Each pair of
+// bars is scaled against the larger of the two values.
+func formatStatsChart(home, away api.TeamStatistics, rows []statRow) string {
+	var b strings.Builder
+
+	homeFav := isFavTeam(home.Team.ID, favTeam)
+	awayFav := isFavTeam(away.Team.ID, favTeam)
+
+	fmt.Fprintf(&b, "%-16s %6s %*s %s\n", "", "", statBarWidth, home.Team.Name, away.Team.Name)
+
+	for _, row := range rows {
+		homeStat, homeOK := home.Get(row.Type)
+		awayStat, awayOK := away.Get(row.Type)
+		if !homeOK && !awayOK {
+			continue
+		}
+
+		homeValue, _ := homeStat.Number()
+		awayValue, _ := awayStat.Number()
+
+		homeLength, awayLength := 0, 0
+		if max := math.Max(homeValue, awayValue); max > 0 {
+			homeLength = int(math.Round(homeValue / max * statBarWidth))
+			awayLength = int(math.Round(awayValue / max * statBarWidth))
+		}
+
+		fmt.Fprintf(&b, "%-16s %6s %s%s|%s%s %s\n",
+			row.Label,
+			formatStatValue(homeStat),
+			strings.Repeat(" ", statBarWidth-homeLength),
+			statBar(homeLength, homeFav),
+			statBar(awayLength, awayFav),
+			strings.Repeat(" ", statBarWidth-awayLength),
+			formatStatValue(awayStat),
+		)
+	}
+
+	return b.String()
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats <fixtureID>",
+	Short: "Displays the match statistics of a fixture",
+	Long: `Displays the match statistics of a fixture given a 'fixtureID'.
+
+Possession, shots, corners, fouls, cards, passes and expected goals are shown as a bar chart comparing both teams.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		// Get the config
+		err := GetConfig()
+		if err != nil {
+			exitWithError("Error loading config:", err)
+		}
+
+		fixtureID, err := strconv.Atoi(args[0])
+		if err != nil {
+			exitWithError("Invalid fixture ID:", err)
+		}
+
+		// The fixture includes its statistics
+		err = checkQuota(1 + extraRequests(false, favTeam != ""))
+		if err != nil {
+			exitWithError("Not enough API quota:", err)
+		}
+
+		client := newClient()
+		match, err := client.FixtureByID(fixtureID)
+		if err != nil {
+			exitWithError("Error fetching and parsing:", err)
+		}
+
+		statistics := match.Statistics
+		if len(statistics) < 2 {
+			fmt.Println("No statistics are available for this fixture yet.")
+			return
+		}
+
+		loadFavTeam(client)
+		fmt.Print(formatStatsChart(statistics[0], statistics[1], statRows))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+
+	statsCmd.Example = ` # Show the statistics for fixture with ID 1234
+premcli stats 1234`
+}
@@ -0,0 +1,205 @@
+/*
+Local store of finished fixtures and standings snapshots, one file per league
+and season. Unlike the response cache it is never cleared, so it builds up the
+history that past tables, charts and ratings are worked out from.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"premcli/api"
+	"sort"
+	"time"
+)
+
+var storePath = filepath.Join(os.Getenv("HOME"), ".local", "share", "premcli", "store")
+
+const (
+	// Snapshot recorded from the API's standings
+	sourceStandings = "standings"
+	// Snapshot rebuilt from fixture results
+	sourceFixtures = "fixtures"
+)
+
+// The table as it stood after a round of the table, by round name
+type snapshot struct {
+	Round      string         `json:"round"`
+	RecordedAt time.Time      `json:"recorded_at"`
+	Source     string         `json:"source"`
+	Standings  []api.Standing `json:"standings"`
+}
+
+type seasonStore struct {
+	League    int         `json:"league"`
+	Season    int         `json:"season"`
+	Matches   []api.Match `json:"matches"`
+	Snapshots []snapshot  `json:"snapshots"`
+}
+
+func storeFile(league, season int) string {
+	return filepath.Join(storePath, fmt.Sprintf("%d-%d.json", league, season))
+}
+
+// Loads the store of a league and season, empty if nothing was stored yet
+func loadStore(league, season int) (*seasonStore, error) {
+	store := &seasonStore{League: league, Season: season}
+
+	data, err := os.ReadFile(storeFile(league, season))
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read store: %v", err)
+	}
+
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("Failed to parse store %s: %v", storeFile(league, season), err)
+	}
+	return store, nil
+}
+
+// Gets the seasons of a league with a store, oldest first
+func storedSeasons(league int) ([]int, error) {
+	paths, err := filepath.Glob(filepath.Join(storePath, fmt.Sprintf("%d-*.json", league)))
+	if err != nil {
+		return nil, err
+	}
+
+	var seasons []int
+	for _, path := range paths {
+		var storeLeague, season int
+		if _, err := fmt.Sscanf(filepath.Base(path), "%d-%d.json", &storeLeague, &season); err == nil && storeLeague == league {
+			seasons = append(seasons, season)
+		}
+	}
+	sort.Ints(seasons)
+	return seasons, nil
+}
+
+// Writes the store to disk
+func (s *seasonStore) save() error {
+	if err := os.MkdirAll(storePath, 0755); err != nil {
+		return fmt.Errorf("Failed to create store directory: %v", err)
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	// Write then rename so an interrupted write never loses the history
+	tmp, err := os.CreateTemp(storePath, "store-*.tmp")
+	if err != nil {
+		return fmt.Errorf("Failed to write store: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("Failed to write store: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("Failed to write store: %v", err)
+	}
+
+	return os.Rename(tmp.Name(), storeFile(s.League, s.Season))
+}
+
+// Adds the finished fixtures among matches, replacing any stored earlier
+func (s *seasonStore) addMatches(matches []api.Match) {
+	index := map[int]int{}
+	for i, match := range s.Matches {
+		index[match.Fixture.ID] = i
+	}
+
+	for _, match := range matches {
+		if !countsInTable(match.Fixture.Status.Short) {
+			continue
+		}
+		// Only the result is needed to rebuild tables
+		match.Events = nil
+		match.Statistics = nil
+
+		if i, exists := index[match.Fixture.ID]; exists {
+			s.Matches[i] = match
+			continue
+		}
+		index[match.Fixture.ID] = len(s.Matches)
+		s.Matches = append(s.Matches, match)
+	}
+
+	sortMatches(s.Matches)
+}
+
+// Gets the snapshot taken after a round
+func (s *seasonStore) snapshot(round string) (snapshot, bool) {
+	for _, snap := range s.Snapshots {
+		if snap.Round == round {
+			return snap, true
+		}
+	}
+	return snapshot{}, false
+}
+
+// Stores a snapshot. A snapshot recorded from the API is never replaced by
+// one rebuilt from fixtures.
+func (s *seasonStore) putSnapshot(snap snapshot) {
+	for i, existing := range s.Snapshots {
+		if existing.Round != snap.Round {
+			continue
+		}
+		if existing.Source == sourceStandings && snap.Source == sourceFixtures {
+			return
+		}
+		s.Snapshots[i] = snap
+		return
+	}
+
+	s.Snapshots = append(s.Snapshots, snap)
+}
+
+// Records the API's table as a snapshot when it sits cleanly between rounds,
+// with every team having played the same number of matches. The snapshot is
+// of the round of the table with that number, so qualifying rounds and
+// play-offs are skipped. Tables with groups don't map onto rounds and aren't
+// recorded.
+func recordSnapshot(client *api.Client, standings []api.Standings, season int) error {
+	if len(standings) != 1 || len(standings[0].League.Standings) != 1 {
+		return nil
+	}
+
+	table := standings[0].League.Standings[0]
+	if len(table) == 0 {
+		return nil
+	}
+
+	played := table[0].All.Played
+	for _, standing := range table {
+		if standing.All.Played != played {
+			return nil
+		}
+	}
+	if played == 0 {
+		return nil
+	}
+
+	rounds, err := client.Rounds(league, season)
+	if err != nil {
+		return err
+	}
+	rounds = tableRounds(rounds)
+	if played > len(rounds) {
+		return nil
+	}
+
+	store, err := loadStore(league, season)
+	if err != nil {
+		return err
+	}
+
+	store.putSnapshot(snapshot{Round: rounds[played-1], RecordedAt: time.Now(), Source: sourceStandings, Standings: table})
+	return store.save()
+}
@@ -0,0 +1,63 @@
+/*
+Table renderer that lines up columns by their visible width. Unlike tabwriter it
+ignores ANSI colour codes, so cells and whole rows can be coloured.
+*/
+package cmd
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Matches the SGR escape sequences used for colours and styles
+var ansiPattern = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// Gets the width of text as shown in a terminal, ignoring colour codes
+func visibleWidth(text string) int {
+	return utf8.RuneCountInString(ansiPattern.ReplaceAllString(text, ""))
+}
+
+// A table with right-aligned columns separated by "|", laid out the way
+// tabwriter.Debug|tabwriter.AlignRight does
+type table struct {
+	Padding int
+	Rows    [][]string
+}
+
+func newTable(header ...string) *table {
+	return &table{Padding: 2, Rows: [][]string{header}}
+}
+
+func (t *table) AddRow(cells ...string) {
+	t.Rows = append(t.Rows, cells)
+}
+
+// Writes the table with each column as wide as its widest cell plus padding
+func (t *table) Render(w io.Writer) error {
+	var widths []int
+	for _, row := range t.Rows {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			if width := visibleWidth(cell); width > widths[i] {
+				widths[i] = width
+			}
+		}
+	}
+
+	var b strings.Builder
+	for _, row := range t.Rows {
+		for i, cell := range row {
+			b.WriteString(strings.Repeat(" ", widths[i]+t.Padding-visibleWidth(cell)))
+			b.WriteString(cell)
+			b.WriteString("|")
+		}
+		b.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
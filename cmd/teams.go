@@ -0,0 +1,295 @@
+/*
+Registry of the teams in the configured league and season, loaded from the API
+so promoted clubs are known without a new release. Teams can be given by their
+code, full name, a common alias or a close misspelling of one.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"premcli/api"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+
+	"github.com/spf13/cobra"
+)
+
+type team struct {
+	ID      int
+	Code    string
+	Name    string
+	Aliases []string
+}
+
+// Nicknames and the codes used by broadcasters, keyed by API team ID
+var teamAliases = map[int][]string{
+	33:   {"Man Utd", "Man United", "Manchester Utd"},
+	34:   {"Newcastle United", "Magpies"},
+	35:   {"AFC Bournemouth", "Cherries"},
+	36:   {"Cottagers"},
+	38:   {"Hornets"},
+	39:   {"Wolverhampton", "Wolverhampton Wanderers"},
+	40:   {"LFC"},
+	41:   {"Saints"},
+	42:   {"Gunners"},
+	44:   {"Clarets"},
+	45:   {"Toffees"},
+	46:   {"Leicester City", "Foxes"},
+	47:   {"Tottenham Hotspur", "Spurs"},
+	48:   {"WHU", "West Ham United", "Hammers"},
+	49:   {"CFC"},
+	50:   {"MCI", "Man City"},
+	51:   {"BHA", "Brighton & Hove Albion", "Seagulls"},
+	52:   {"Palace", "Eagles"},
+	55:   {"Bees"},
+	57:   {"Ipswich Town", "Tractor Boys"},
+	62:   {"SHU", "Sheffield United", "Blades"},
+	63:   {"Leeds United"},
+	65:   {"NFO", "Nott'm Forest", "Forest"},
+	66:   {"AVL", "Villa"},
+	71:   {"Norwich City", "Canaries"},
+	746:  {"Sunderland AFC", "Black Cats"},
+	1359: {"Luton Town", "Hatters"},
+}
+
+// Teams of the configured league and season, filled in by loadTeams
+var teams []team
+
+// Loads the teams of the configured league and season. The list is cached for
+// a week as it only changes between seasons.
+func loadTeams(client *api.Client) error {
+	infos, err := client.Teams(league, getSeason(client))
+	if err != nil {
+		return err
+	}
+	if len(infos) == 0 {
+		return fmt.Errorf("No teams found for season %d", getSeason(client))
+	}
+
+	teams = nil
+	for _, info := range infos {
+		teams = append(teams, team{
+			ID:      info.Team.ID,
+			Code:    info.Team.Code,
+			Name:    info.Team.Name,
+			Aliases: teamAliases[info.Team.ID],
+		})
+	}
+
+	sort.Slice(teams, func(i, j int) bool {
+		return teams[i].Name < teams[j].Name
+	})
+	return nil
+}
+
+// Loads the teams so the favourite team can be highlighted. Highlighting is
+// only cosmetic, so problems are printed as warnings.
+func loadFavTeam(client *api.Client) {
+	if favTeam == "" {
+		return
+	}
+
+	if err := loadTeams(client); err != nil {
+		fmt.Fprintln(os.Stderr, "Warning: couldn't load teams to highlight your favourite team:", err)
+		return
+	}
+	if _, err := resolveTeam(favTeam); err != nil {
+		fmt.Fprintln(os.Stderr, "Warning: FAVTEAM:", err)
+	}
+}
+
+// Lowercases a team name and drops punctuation, e.g. "Nott'm Forest" becomes
+// "nottm forest". "&" is spelt out, so "Brighton & Hove Albion" and "Brighton
+// and Hove Albion" are the same name.
+func normaliseTeam(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		case unicode.IsSpace(r) || r == '-':
+			b.WriteRune(' ')
+		case r == '&':
+			b.WriteString(" and ")
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// Every name a team can be given by, normalised
+func (t team) names() []string {
+	names := []string{normaliseTeam(t.Code), normaliseTeam(t.Name)}
+	for _, alias := range t.Aliases {
+		names = append(names, normaliseTeam(alias))
+	}
+	return names
+}
+
+// Counts the single character edits needed to turn a into b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+// Shortest query checked for typos. Codes are shorter, and one letter off an
+// old code is often another club's, e.g. "LEI" and "LEE".
+const minTypoLength = 4
+
+// How many typos are tolerated in a query of the given length
+func typoAllowance(length int) int {
+	switch {
+	case length < minTypoLength:
+		return 0
+	case length <= 5:
+		return 1
+	case length <= 9:
+		return 2
+	}
+	return 3
+}
+
+// Lists teams as "Name (CODE)" for error messages
+func describeTeams(candidates []team) string {
+	var names []string
+	for _, t := range candidates {
+		names = append(names, fmt.Sprintf("%s (%s)", t.Name, t.Code))
+	}
+	return strings.Join(names, ", ")
+}
+
+// Picks the single team out of candidates or explains why it can't
+func pickTeam(query string, candidates []team) (team, error) {
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+	return team{}, fmt.Errorf("%q is ambiguous, did you mean %s?", query, describeTeams(candidates))
+}
+
+// Resolves a team given by its code, full name or alias. Names are compared
+// without case or punctuation, a whole-word prefix such as "Nottingham" is
+// enough when only one team matches, and small typos in names longer than a
+// code are forgiven. Ambiguous
+// input is an error that lists the candidates.
+func resolveTeam(query string) (team, error) {
+	normalised := normaliseTeam(query)
+	if normalised == "" {
+		return team{}, fmt.Errorf("No team given")
+	}
+
+	// Exact match on any name
+	for _, t := range teams {
+		for _, name := range t.names() {
+			if name == normalised {
+				return t, nil
+			}
+		}
+	}
+
+	// Whole-word prefix of a name, e.g. "man" or "crystal"
+	var candidates []team
+	for _, t := range teams {
+		for _, name := range t.names() {
+			if strings.HasPrefix(name, normalised+" ") {
+				candidates = append(candidates, t)
+				break
+			}
+		}
+	}
+	if len(candidates) > 0 {
+		return pickTeam(query, candidates)
+	}
+
+	// Closest full name or alias within the typo allowance. Codes and queries
+	// as short as a code must match exactly.
+	if len([]rune(normalised)) < minTypoLength {
+		return team{}, fmt.Errorf("Unknown team %q, use 'premcli teams' to list the teams", query)
+	}
+	best := typoAllowance(len([]rune(normalised)))
+	for _, t := range teams {
+		distance := best + 1
+		for _, name := range t.names() {
+			if len([]rune(name)) < minTypoLength {
+				continue
+			}
+			distance = min(distance, editDistance(normalised, name))
+		}
+		switch {
+		case distance < best:
+			best = distance
+			candidates = []team{t}
+		case distance == best:
+			candidates = append(candidates, t)
+		}
+	}
+	if len(candidates) > 0 {
+		return pickTeam(query, candidates)
+	}
+
+	return team{}, fmt.Errorf("Unknown team %q, use 'premcli teams' to list the teams", query)
+}
+
+// Checks if the team with the given API ID is the team given by code, name or alias
+func isFavTeam(teamID int, query string) bool {
+	if teamID == 0 || query == "" {
+		return false
+	}
+
+	t, err := resolveTeam(query)
+	return err == nil && t.ID == teamID
+}
+
+var teamsCmd = &cobra.Command{
+	Use:   "teams",
+	Short: "Lists the teams of the followed league and season",
+	Long: `Lists the code, name and aliases of every team in the followed league and season.
+
+Any of them can be used for FAVTEAM in the config or for the --team flag.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Get the config
+		err := GetConfig()
+		if err != nil {
+			exitWithError("Error loading config:", err)
+		}
+
+		err = checkQuota(extraRequests(false, true))
+		if err != nil {
+			exitWithError("Not enough API quota:", err)
+		}
+
+		err = loadTeams(newClient())
+		if err != nil {
+			exitWithError("Error fetching and parsing:", err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Code\tName\tAliases")
+		for _, t := range teams {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", t.Code, t.Name, strings.Join(t.Aliases, ", "))
+		}
+		w.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(teamsCmd)
+}
@@ -0,0 +1,82 @@
+/*
+Tests that teams resolve from their codes, names, aliases, prefixes and small
+typos, and that ambiguous or unknown names are refused.
+*/
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+// Loads a fixed list of teams for a test
+func useTeams(t *testing.T) {
+	oldTeams := teams
+	teams = []team{
+		{ID: 42, Code: "ARS", Name: "Arsenal", Aliases: teamAliases[42]},
+		{ID: 51, Code: "BRI", Name: "Brighton", Aliases: teamAliases[51]},
+		{ID: 63, Code: "LEE", Name: "Leeds", Aliases: teamAliases[63]},
+		{ID: 33, Code: "MUN", Name: "Manchester United", Aliases: teamAliases[33]},
+		{ID: 50, Code: "MAC", Name: "Manchester City", Aliases: teamAliases[50]},
+		{ID: 65, Code: "NOT", Name: "Nottingham Forest", Aliases: teamAliases[65]},
+		{ID: 47, Code: "TOT", Name: "Tottenham", Aliases: teamAliases[47]},
+		{ID: 39, Code: "WOL", Name: "Wolves", Aliases: teamAliases[39]},
+	}
+	t.Cleanup(func() {
+		teams = oldTeams
+	})
+}
+
+func TestResolveTeam(t *testing.T) {
+	useTeams(t)
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"WOL", 39},
+		{"wolves", 39},
+		{"Wolverhampton Wanderers", 39},
+		{"Spurs", 47},
+		{"Nott'm Forest", 65},
+		{"Nottingham", 65},
+		{"Brighton & Hove Albion", 51},
+		{"Brighton and Hove Albion", 51},
+		{"man city", 50},
+		{"Arsenel", 42},
+	}
+
+	for _, test := range tests {
+		got, err := resolveTeam(test.query)
+		if err != nil {
+			t.Errorf("resolveTeam(%q): %v", test.query, err)
+			continue
+		}
+		if got.ID != test.want {
+			t.Errorf("resolveTeam(%q) = %s, want team %d", test.query, got.Name, test.want)
+		}
+	}
+}
+
+func TestResolveTeamErrors(t *testing.T) {
+	useTeams(t)
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "No team given"},
+		{"Manchester", "ambiguous"},
+		{"Barcelona", "Unknown team"},
+		// Codes of teams outside the league aren't taken for a close one
+		{"LEI", "Unknown team"},
+		{"NOR", "Unknown team"},
+	}
+
+	for _, test := range tests {
+		_, err := resolveTeam(test.query)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("resolveTeam(%q) = %v, want an error containing %q", test.query, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,468 @@
+/*
+Full-screen dashboard with tabs for the fixtures of a round, the standings
+table and a live match pane.
+*/
+package cmd
+
+import (
+	"fmt"
+	"premcli/api"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+const (
+	tabFixtures = iota
+	tabStandings
+	tabLive
+)
+
+var tabNames = []string{"Fixtures", "Standings", "Live"}
+
+// How often live matches are refreshed in the background
+const tuiRefreshInterval = 30 * time.Second
+
+var (
+	tuiActiveTabStyle = lipgloss.NewStyle().Bold(true).Underline(true).Padding(0, 1)
+	tuiTabStyle       = lipgloss.NewStyle().Faint(true).Padding(0, 1)
+	tuiTitleStyle     = lipgloss.NewStyle().Underline(true)
+	tuiSelectedStyle  = lipgloss.NewStyle().Reverse(true)
+	tuiFavStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("5"))
+	tuiHelpStyle      = lipgloss.NewStyle().Faint(true)
+	tuiErrorStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+)
+
+// Messages sent back by the commands that fetch data
+type roundMsg struct {
+	request int
+	round   string
+	matches []api.Match
+}
+
+type standingsMsg []api.Standings
+
+type liveMsg api.Match
+
+type tickMsg time.Time
+
+type errMsg struct{ err error }
+
+// Error fetching a round, tagged like roundMsg so a stale one can be dropped
+type roundErrMsg struct {
+	request int
+	err     error
+}
+
+type tuiModel struct {
+	client *api.Client
+	season int
+
+	tab     int
+	width   int
+	height  int
+	loading bool
+	err     error
+
+	round    string
+	matches  []api.Match
+	selected int
+	// Number of the latest round asked for. Replies to earlier requests are
+	// out of date and dropped.
+	roundRequest int
+
+	standings []api.Standings
+
+	live       *api.Match
+	liveOffset int
+}
+
+// Fetches the fixtures of the round offset rounds away from round, which is
+// a round name or "current". The reply is tagged with the latest request.
+func (m tuiModel) fetchRound(round string, offset int) tea.Cmd {
+	request := m.roundRequest
+	return func() tea.Msg {
+		round, err := resolveRound(m.client, round, offset)
+		if err != nil {
+			return roundErrMsg{request, err}
+		}
+
+		matches, err := m.client.Fixtures(league, m.season, round, timezone)
+		if err != nil {
+			return roundErrMsg{request, err}
+		}
+
+		sortMatches(matches)
+		return roundMsg{request: request, round: round, matches: matches}
+	}
+}
+
+func (m tuiModel) fetchStandings() tea.Cmd {
+	return func() tea.Msg {
+		standings, err := m.client.Standings(league, m.season)
+		if err != nil {
+			return errMsg{err}
+		}
+		return standingsMsg(standings)
+	}
+}
+
+func (m tuiModel) fetchLive(fixtureID int) tea.Cmd {
+	return func() tea.Msg {
+		match, err := m.client.FixtureByID(fixtureID)
+		if err != nil {
+			return errMsg{err}
+		}
+		return liveMsg(match)
+	}
+}
+
+// Schedules the next background refresh, slowing down when the quota runs low
+func tick() tea.Cmd {
+	return tea.Tick(watchBackoff(tuiRefreshInterval), func(t time.Time) tea.Msg {
+		return tickMsg(t)
+	})
+}
+
+func (m tuiModel) Init() tea.Cmd {
+	return tea.Batch(m.fetchRound("current", 0), m.fetchStandings(), tick())
+}
+
+func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+
+	case roundMsg:
+		if msg.request != m.roundRequest {
+			return m, nil
+		}
+		m.loading = false
+		m.err = nil
+		if msg.round != m.round {
+			m.selected = 0
+		}
+		m.round = msg.round
+		m.matches = msg.matches
+
+	case standingsMsg:
+		m.standings = msg
+
+	case liveMsg:
+		match := api.Match(msg)
+		m.loading = false
+		m.err = nil
+		m.live = &match
+
+	case roundErrMsg:
+		if msg.request != m.roundRequest {
+			return m, nil
+		}
+		m.loading = false
+		m.err = msg.err
+
+	case errMsg:
+		m.loading = false
+		m.err = msg.err
+
+	case tickMsg:
+		// Only live matches change, so only they are refreshed. The round isn't
+		// refreshed while the user is moving to another one.
+		var cmds []tea.Cmd
+		for _, match := range m.matches {
+			if !m.loading && api.IsLive(match.Fixture.Status.Short) {
+				cmds = append(cmds, m.fetchRound(m.round, 0))
+				break
+			}
+		}
+		if m.live != nil && api.IsLive(m.live.Fixture.Status.Short) {
+			cmds = append(cmds, m.fetchLive(m.live.Fixture.ID))
+		}
+		return m, tea.Batch(append(cmds, tick())...)
+
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+
+	return m, nil
+}
+
+func (m tuiModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	case "tab":
+		m.tab = (m.tab + 1) % len(tabNames)
+		return m, nil
+	case "shift+tab":
+		m.tab = (m.tab + len(tabNames) - 1) % len(tabNames)
+		return m, nil
+	case "1", "2", "3":
+		m.tab = int(msg.String()[0] - '1')
+		return m, nil
+	}
+
+	switch m.tab {
+	case tabFixtures:
+		switch msg.String() {
+		case "up", "k":
+			if m.selected > 0 {
+				m.selected--
+			}
+		case "down", "j":
+			if m.selected < len(m.matches)-1 {
+				m.selected++
+			}
+		case "left", "h", "p", "right", "l", "n":
+			offset := 1
+			if key := msg.String(); key == "left" || key == "h" || key == "p" {
+				offset = -1
+			}
+			m.loading = true
+			m.roundRequest++
+			return m, m.fetchRound(m.round, offset)
+		case "c":
+			m.loading = true
+			m.roundRequest++
+			return m, m.fetchRound("current", 0)
+		case "enter":
+			if len(m.matches) == 0 {
+				return m, nil
+			}
+			m.tab = tabLive
+			m.loading = true
+			m.liveOffset = 0
+			return m, m.fetchLive(m.matches[m.selected].Fixture.ID)
+		}
+
+	case tabLive:
+		switch msg.String() {
+		case "up", "k":
+			if m.liveOffset > 0 {
+				m.liveOffset--
+			}
+		case "down", "j":
+			if m.liveOffset < m.maxLiveOffset() {
+				m.liveOffset++
+			}
+		case "r":
+			if m.live != nil {
+				m.loading = true
+				return m, m.fetchLive(m.live.Fixture.ID)
+			}
+		}
+
+	case tabStandings:
+		if msg.String() == "r" {
+			return m, m.fetchStandings()
+		}
+	}
+
+	return m, nil
+}
+
+// Formats a fixture as a single line of the fixtures tab
+func formatFixtureLine(match api.Match) string {
+	kickoff, err := FormatTime(match.Fixture.Date)
+	if err != nil {
+		kickoff = match.Fixture.Date
+	}
+
+	score := "vs."
+	status := ""
+	if match.Fixture.Status.Short != "NS" {
+		score = fmt.Sprintf("%d - %d", match.Goals.Home, match.Goals.Away)
+		if api.IsLive(match.Fixture.Status.Short) {
+			status = fmt.Sprintf("%d'", match.Fixture.Status.Elapsed)
+		} else {
+			status = match.Fixture.Status.Short
+		}
+	}
+
+	return fmt.Sprintf("%-21s  %22s %-7s %-22s %s", kickoff, match.Teams.Home.Name, centre(score, 7), match.Teams.Away.Name, status)
+}
+
+// Centres text in a field of the given width
+func centre(text string, width int) string {
+	if len(text) >= width {
+		return text
+	}
+	left := (width - len(text)) / 2
+	return strings.Repeat(" ", left) + text + strings.Repeat(" ", width-len(text)-left)
+}
+
+func (m tuiModel) viewFixtures() string {
+	var b strings.Builder
+
+	b.WriteString(tuiTitleStyle.Render(m.round) + "\n\n")
+	for i, match := range m.matches {
+		line := formatFixtureLine(match)
+		switch {
+		case i == m.selected:
+			line = tuiSelectedStyle.Render(line)
+		case playsIn(match, favTeam):
+			line = tuiFavStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+
+	b.WriteString("\n" + tuiHelpStyle.Render("↑/↓ select • ←/→ previous/next round • c current round • enter events"))
+	return b.String()
+}
+
+func (m tuiModel) viewStandings() string {
+	var b strings.Builder
+
+	for _, leagueData := range m.standings {
+		for _, group := range leagueData.League.Standings {
+			b.WriteString(tuiTitleStyle.Render(groupTitle(leagueData, group)) + "\n")
+			b.WriteString(fmt.Sprintf("%4s  %-24s %3s %3s %3s %3s %4s %4s %4s %4s  %s\n", "Rank", "Club", "MP", "W", "D", "L", "GF", "GA", "GD", "Pts", "Form"))
+			for _, standing := range group {
+				line := fmt.Sprintf("%4d  %-24s %3d %3d %3d %3d %4d %4d %4d %4d  %s",
+					standing.Rank,
+					standing.Team.Name,
+					standing.All.Played,
+					standing.All.Win,
+					standing.All.Draw,
+					standing.All.Lose,
+					standing.All.Goals.For,
+					standing.All.Goals.Against,
+					standing.GoalsDiff,
+					standing.Points,
+					standing.Form,
+				)
+				if isFavTeam(standing.Team.ID, favTeam) {
+					line = tuiFavStyle.Render(line)
+				}
+				b.WriteString(line + "\n")
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	b.WriteString(tuiHelpStyle.Render("r refresh"))
+	return b.String()
+}
+
+func (m tuiModel) viewLive() string {
+	if m.live == nil {
+		return "Select a fixture on the Fixtures tab and press enter to follow it.\n"
+	}
+
+	scoreboard, err := formatScoreboard(*m.live)
+	if err != nil {
+		return tuiErrorStyle.Render(err.Error())
+	}
+
+	// Scroll through the events, keeping the scoreboard in view. The window
+	// may have shrunk since the offset was last clamped.
+	lines, available := m.liveLines(scoreboard)
+	offset := min(m.liveOffset, max(0, len(lines)-available))
+	end := offset + available
+	if end > len(lines) {
+		end = len(lines)
+	}
+
+	return scoreboard + "\n" + strings.Join(lines[offset:end], "\n") + "\n" +
+		tuiHelpStyle.Render("↑/↓ scroll • r refresh • live matches refresh automatically")
+}
+
+// Gets the event lines of the followed fixture and how many fit below the
+// scoreboard
+func (m tuiModel) liveLines(scoreboard string) ([]string, int) {
+	var events []string
+	for _, event := range m.live.Events {
+		events = append(events, formatEvent(event))
+	}
+
+	lines := strings.Split(strings.Join(events, "\n"), "\n")
+	available := m.height - strings.Count(scoreboard, "\n") - 6
+	if available < 1 {
+		available = len(lines)
+	}
+	return lines, available
+}
+
+// Gets how far the events of the followed fixture can be scrolled
+func (m tuiModel) maxLiveOffset() int {
+	if m.live == nil {
+		return 0
+	}
+	scoreboard, err := formatScoreboard(*m.live)
+	if err != nil {
+		return 0
+	}
+	lines, available := m.liveLines(scoreboard)
+	return max(0, len(lines)-available)
+}
+
+func (m tuiModel) View() string {
+	var b strings.Builder
+
+	var tabs []string
+	for i, name := range tabNames {
+		if i == m.tab {
+			tabs = append(tabs, tuiActiveTabStyle.Render(name))
+		} else {
+			tabs = append(tabs, tuiTabStyle.Render(name))
+		}
+	}
+	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, tabs...) + "\n\n")
+
+	switch m.tab {
+	case tabFixtures:
+		b.WriteString(m.viewFixtures())
+	case tabStandings:
+		b.WriteString(m.viewStandings())
+	case tabLive:
+		b.WriteString(m.viewLive())
+	}
+
+	b.WriteString("\n")
+	if m.loading {
+		b.WriteString("Loading...\n")
+	}
+	if m.err != nil {
+		b.WriteString(tuiErrorStyle.Render(m.err.Error()) + "\n")
+	}
+	b.WriteString(tuiHelpStyle.Render("tab switch tabs • q quit"))
+
+	return b.String()
+}
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Opens an interactive dashboard",
+	Long: `Opens an interactive dashboard with tabs for the current round's fixtures, the standings and a live match pane.
+
+Use the arrow keys to move between fixtures and rounds and press enter on a fixture to follow its events. Live matches are refreshed in the background.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Get the config
+		err := GetConfig()
+		if err != nil {
+			exitWithError("Error loading config:", err)
+		}
+
+		err = checkQuota(3 + extraRequests(true, favTeam != ""))
+		if err != nil {
+			exitWithError("Not enough API quota:", err)
+		}
+
+		client := newClient()
+		loadFavTeam(client)
+
+		model := tuiModel{client: client, season: getSeason(client), loading: true}
+
+		_, err = tea.NewProgram(model, tea.WithAltScreen()).Run()
+		if err != nil {
+			exitWithError("Error running dashboard:", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tuiCmd)
+}
@@ -8,41 +8,17 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"premcli/api"
 	"strings"
 	"time"
 )
 
-var teamMapping = map[string]string{
-	"ARS": "Arsenal",
-	"AVL": "Aston Villa",
-	"BOU": "Bournemouth",
-	"BRE": "Brentford",
-	"BHA": "Brighton",
-	"BUR": "Burnley",
-	"CHE": "Chelsea",
-	"CRY": "Crystal Palace",
-	"EVE": "Everton",
-	"FUL": "Fulham",
-	"LEI": "Leicester City",
-	"LIV": "Liverpool",
-	"LUT": "Luton",
-	"MCI": "Manchester City",
-	"MUN": "Manchester United",
-	"NEW": "Newcastle",
-	"NOR": "Norwich City",
-	"NOT": "Nottingham Forest",
-	"SHU": "Sheffield United",
-	"SOU": "Southampton",
-	"TOT": "Tottenham Hotspur",
-	"WAT": "Watford",
-	"WHU": "West Ham",
-	"WOL": "Wolves",
-}
-
 var (
 	apiKey   string
 	timezone string
 	favTeam  string
+	// Set by --season or worked out by getSeason
+	season int
 )
 
 // Retrieves the config information
@@ -76,6 +52,10 @@ func GetConfig() error {
 			timezone = value
 		case "FAVTEAM":
 			favTeam = value
+		case "LEAGUE":
+			leagueSetting = value
+		case "TIEBREAKERS":
+			tiebreakSetting = value
 		default:
 			return fmt.Errorf("Unknown config key: %s", key)
 		}
@@ -85,21 +65,31 @@ func GetConfig() error {
 		return fmt.Errorf("Error reading config file: %v", err)
 	}
 
-	return nil
-}
+	// --league overrides the config
+	setting := leagueSetting
+	if leagueFlag != "" {
+		setting = leagueFlag
+	}
+	league, err = parseLeague(setting)
+	if err != nil {
+		return err
+	}
 
-// Checks if favourite team is present within string
-func isFavTeam(matchString, favTeam string) bool {
-	teamName, exists := teamMapping[strings.ToUpper(favTeam)]
-	if !exists {
-		return false
+	if tiebreakSetting != "" {
+		if _, err := parseTiebreakers(tiebreakSetting); err != nil {
+			return err
+		}
+	}
+
+	if season < 0 || (season > 0 && season < 1900) {
+		return fmt.Errorf("Invalid season %d, use the year it starts in, e.g. 2021", season)
 	}
 
-	return strings.Contains(strings.ToUpper(matchString), strings.ToUpper(teamName))
+	return nil
 }
 
-// Gets the current season year
-func getSeasonYear() string {
+// Guesses the current season year, assuming seasons start in July
+func guessSeasonYear() int {
 	currentYear := time.Now().Year()
 	currentMonth := time.Now().Month()
 
@@ -107,5 +97,55 @@ func getSeasonYear() string {
 		currentYear--
 	}
 
-	return fmt.Sprintf("%d", currentYear)
+	return currentYear
+}
+
+// Gets the season to show. --season wins, otherwise the followed league is
+// asked which of its seasons is current. Leagues such as MLS play in a calendar
+// year, so the July guess is only used when the API can't answer.
+func getSeason(client *api.Client) int {
+	if season != 0 {
+		return season
+	}
+
+	season = guessSeasonYear()
+	if info, err := client.League(league); err == nil {
+		for _, s := range info.Seasons {
+			if s.Current {
+				season = s.Year
+			}
+		}
+	}
+	return season
+}
+
+// Prints the error and exits. API errors exit with their own code so scripts
+// can tell an invalid key from an exhausted quota.
+func exitWithError(msg string, err error) {
+	fmt.Fprintln(os.Stderr, msg, err)
+
+	var apiErr *api.Error
+	if errors.As(err, &apiErr) {
+		os.Exit(apiErr.ExitCode())
+	}
+	os.Exit(1)
+}
+
+// Creates an API client from the loaded config.
+// PREMCLI_API_URL overrides the base URL, e.g. to point premcli at a local test server.
+func newClient() *api.Client {
+	client := api.NewClient(apiKey)
+	client.Cache = api.NewCache(responseCachePath)
+	client.QuotaPath = quotaPath
+
+	if noCache {
+		client.CacheMode = api.CacheOff
+	} else if refreshCache {
+		client.CacheMode = api.CacheRefresh
+	}
+
+	if baseURL := os.Getenv("PREMCLI_API_URL"); baseURL != "" {
+		client.BaseURL = baseURL
+	}
+	return client
 }